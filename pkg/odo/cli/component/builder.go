@@ -0,0 +1,520 @@
+package component
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/openshift/odo/pkg/devfile/adapters/common"
+	"github.com/openshift/odo/pkg/log"
+	"github.com/openshift/odo/pkg/pipelines/namespaces"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// Supported --builder values.
+const (
+	DockerBuilder   = "docker"
+	BuildahBuilder  = "buildah"
+	KanikoBuilder   = "kaniko"
+	BuildKitBuilder = "buildkit"
+)
+
+// BuiltImage is a locally or remotely produced image ready to be pushed.
+type BuiltImage interface {
+	Push(dockerConfigJSONFilename string) error
+}
+
+// BuildOptions carries the Dockerfile-path build flags that every backend
+// should honor: --cache-from references to pull layer cache from instead of
+// requiring a local parent chain, and --squash to collapse the produced
+// layers onto the base FROM image before push.
+type BuildOptions struct {
+	CacheFrom []string
+	Squash    bool
+}
+
+// ImageBuilder builds the Dockerfile and S2I paths of `odo deploy` against
+// a specific backend (Docker, Buildah, Kaniko, BuildKit), so rootless or
+// daemonless environments where the Docker socket isn't available can
+// still run `odo deploy`.
+type ImageBuilder interface {
+	BuildFromDockerfile(contextDir string, dockerfile []byte, tag string, opts BuildOptions) (BuiltImage, error)
+	NewS2IBuild(builderImage string) (S2IBuild, error)
+}
+
+// S2IBuild is the subset of common.ImageBuilder used by the S2I path.
+type S2IBuild interface {
+	CopySource(sourceDir, destDir string, ignores []string) error
+	Run(script string, env []corev1.EnvVar) error
+	SetEntrypoint(entrypoint []string)
+	Commit(tag string) (BuiltImage, error)
+}
+
+// NewImageBuilder returns the ImageBuilder backend named by kind, which
+// must be one of DockerBuilder, BuildahBuilder, KanikoBuilder or
+// BuildKitBuilder. Kaniko additionally needs the Context's namespace to
+// run its build pod in, and dockerConfigJSONFilename to authenticate its
+// push: unlike the other backends, Kaniko runs in-cluster and has no
+// access to the caller's local config.json, so it's materialized as a
+// Secret in namespace for the build pod to mount.
+func NewImageBuilder(kind, namespace, dockerConfigJSONFilename string) (ImageBuilder, error) {
+	switch kind {
+	case "", DockerBuilder:
+		return dockerImageBuilder{}, nil
+	case BuildahBuilder:
+		return buildahImageBuilder{}, nil
+	case KanikoBuilder:
+		return kanikoImageBuilder{namespace: namespace, dockerConfigJSONFilename: dockerConfigJSONFilename}, nil
+	case BuildKitBuilder:
+		return buildkitImageBuilder{}, nil
+	default:
+		return nil, fmt.Errorf("unknown --builder %q: expected one of docker, buildah, kaniko, buildkit", kind)
+	}
+}
+
+// dockerImageBuilder is the pre-existing backend, using the Docker socket
+// via common.ImageBuilder.
+type dockerImageBuilder struct{}
+
+func (dockerImageBuilder) BuildFromDockerfile(contextDir string, dockerfile []byte, tag string, opts BuildOptions) (BuiltImage, error) {
+	builder, err := common.NewImageBuilder("")
+	if err != nil {
+		return nil, err
+	}
+	return builder.BuildFromDockerfile(contextDir, dockerfile, tag, common.BuildOptions{
+		CacheFrom: opts.CacheFrom,
+		Squash:    opts.Squash,
+	})
+}
+
+func (dockerImageBuilder) NewS2IBuild(builderImage string) (S2IBuild, error) {
+	return common.NewImageBuilder(builderImage)
+}
+
+// buildahImageBuilder shells out to the buildah CLI, for rootless hosts
+// without a Docker daemon.
+type buildahImageBuilder struct{}
+
+func (buildahImageBuilder) BuildFromDockerfile(contextDir string, dockerfile []byte, tag string, opts BuildOptions) (BuiltImage, error) {
+	args := []string{"bud", "-t", tag}
+	for _, ref := range opts.CacheFrom {
+		args = append(args, "--cache-from", ref)
+	}
+	if opts.Squash {
+		args = append(args, "--squash")
+	}
+	args = append(args, "-f", "/dev/stdin", contextDir)
+	if err := runCommandWithStdin(contextDir, bytes.NewReader(dockerfile), "buildah", args...); err != nil {
+		return nil, fmt.Errorf("buildah bud failed: %w", err)
+	}
+	return cliBuiltImage{tool: "buildah", tag: tag}, nil
+}
+
+func (buildahImageBuilder) NewS2IBuild(builderImage string) (S2IBuild, error) {
+	return nil, fmt.Errorf("the buildah builder does not support Source-to-Image, use --builder docker")
+}
+
+// buildkitImageBuilder shells out to buildctl against a BuildKit daemon.
+type buildkitImageBuilder struct{}
+
+func (buildkitImageBuilder) BuildFromDockerfile(contextDir string, dockerfile []byte, tag string, opts BuildOptions) (BuiltImage, error) {
+	args := []string{
+		"build",
+		"--frontend", "dockerfile.v0",
+		"--local", "context=" + contextDir,
+		"--local", "dockerfile=" + contextDir,
+		"--output", fmt.Sprintf("type=image,name=%s,push=true", tag),
+	}
+	for _, ref := range opts.CacheFrom {
+		args = append(args, "--import-cache", "type=registry,ref="+ref)
+	}
+	if opts.Squash {
+		log.Warning("--squash is not supported by the buildkit builder and will be ignored")
+	}
+	if err := runCommand(contextDir, "buildctl", args...); err != nil {
+		return nil, fmt.Errorf("buildctl build failed: %w", err)
+	}
+	return cliBuiltImage{tool: "buildctl", tag: tag, pushed: true}, nil
+}
+
+func (buildkitImageBuilder) NewS2IBuild(builderImage string) (S2IBuild, error) {
+	return nil, fmt.Errorf("the buildkit builder does not support Source-to-Image, use --builder docker")
+}
+
+// kanikoSourceContainer is the init container kanikoBuildPod waits on
+// before starting the kaniko build: it blocks until BuildFromDockerfile
+// has uploaded the build context into the shared workspace volume.
+const kanikoSourceContainer = "wait-for-source"
+
+// kanikoWaitTimeout bounds how long BuildFromDockerfile waits for the
+// source upload init container to start and for the kaniko build itself
+// to finish.
+const kanikoWaitTimeout = 15 * time.Minute
+
+// kanikoDockerConfigSecretName is the Secret kanikoBuildPod mounts at
+// /kaniko/.docker, populated by ensureDockerConfigSecret from the
+// dockerconfigjson BuildFromDockerfile is configured with.
+const kanikoDockerConfigSecretName = "odo-deploy-dockerconfigjson"
+
+// kanikoImageBuilder runs the build in-cluster as a pod in namespace,
+// mounting the dockerconfigjson as a secret so it can push without a
+// Docker socket on the build host.
+type kanikoImageBuilder struct {
+	namespace                string
+	dockerConfigJSONFilename string
+}
+
+func (b kanikoImageBuilder) BuildFromDockerfile(contextDir string, dockerfile []byte, tag string, opts BuildOptions) (BuiltImage, error) {
+	clientset, err := namespaces.GetClientSet()
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to cluster to run Kaniko build: %w", err)
+	}
+	restConfig, err := kanikoRESTConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to cluster to run Kaniko build: %w", err)
+	}
+
+	if err := ensureDockerConfigSecret(clientset, b.namespace, b.dockerConfigJSONFilename); err != nil {
+		return nil, fmt.Errorf("unable to create %s secret: %w", kanikoDockerConfigSecretName, err)
+	}
+
+	spinner := log.Spinner(fmt.Sprintf("Running Kaniko build pod for %s", tag))
+	defer spinner.End(false)
+
+	pod := kanikoBuildPod(b.namespace, tag, opts)
+	created, err := clientset.CoreV1().Pods(b.namespace).Create(pod)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Kaniko build pod: %w", err)
+	}
+	podName := created.Name
+
+	if err := waitForContainerRunning(clientset, b.namespace, podName, kanikoSourceContainer); err != nil {
+		return nil, fmt.Errorf("source upload container did not start: %w", err)
+	}
+	if err := uploadSourceToPod(clientset, restConfig, b.namespace, podName, kanikoSourceContainer, contextDir, dockerfile); err != nil {
+		return nil, fmt.Errorf("unable to upload build context to %s: %w", podName, err)
+	}
+
+	stopLogs := make(chan struct{})
+	go streamPodLogs(clientset, b.namespace, podName, "kaniko", stopLogs)
+
+	phase, err := waitForPodCompletion(clientset, b.namespace, podName)
+	close(stopLogs)
+	if err != nil {
+		return nil, fmt.Errorf("Kaniko build did not finish: %w", err)
+	}
+	if phase != corev1.PodSucceeded {
+		return nil, fmt.Errorf("Kaniko build pod %s finished with phase %s", podName, phase)
+	}
+
+	spinner.End(true)
+	return cliBuiltImage{tool: "kaniko", tag: tag, pushed: true}, nil
+}
+
+func (kanikoImageBuilder) NewS2IBuild(builderImage string) (S2IBuild, error) {
+	return nil, fmt.Errorf("the kaniko builder does not support Source-to-Image, use --builder docker")
+}
+
+// kanikoBuildPod describes the Kaniko executor pod used to build and push
+// tag from the context uploaded by BuildFromDockerfile into the workspace
+// volume shared with the wait-for-source init container.
+func kanikoBuildPod(namespace, tag string, opts BuildOptions) *corev1.Pod {
+	args := []string{
+		"--dockerfile=/workspace/Dockerfile",
+		"--context=dir:///workspace",
+		"--destination=" + tag,
+	}
+	for _, ref := range opts.CacheFrom {
+		args = append(args, "--cache=true", "--cache-repo="+ref)
+	}
+	if opts.Squash {
+		args = append(args, "--single-snapshot")
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "odo-deploy-kaniko-",
+			Namespace:    namespace,
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			InitContainers: []corev1.Container{
+				{
+					Name:    kanikoSourceContainer,
+					Image:   "busybox",
+					Command: []string{"sh", "-c", "until [ -f /workspace/.odo-source-ready ]; do sleep 1; done"},
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: "workspace", MountPath: "/workspace"},
+					},
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:  "kaniko",
+					Image: "gcr.io/kaniko-project/executor:latest",
+					Args:  args,
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: "dockerconfigjson", MountPath: "/kaniko/.docker"},
+						{Name: "workspace", MountPath: "/workspace"},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "dockerconfigjson",
+					VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{SecretName: kanikoDockerConfigSecretName},
+					},
+				},
+				{
+					Name:         "workspace",
+					VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+				},
+			},
+		},
+	}
+}
+
+// ensureDockerConfigSecret creates or updates the kanikoDockerConfigSecretName
+// Secret in namespace from the config.json at dockerConfigJSONFilename,
+// under the "config.json" key Kaniko's executor image reads from the
+// /kaniko/.docker mount kanikoBuildPod sets up.
+func ensureDockerConfigSecret(clientset kubernetes.Interface, namespace, dockerConfigJSONFilename string) error {
+	data, err := os.ReadFile(dockerConfigJSONFilename)
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %w", dockerConfigJSONFilename, err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kanikoDockerConfigSecretName,
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{"config.json": data},
+	}
+
+	secrets := clientset.CoreV1().Secrets(namespace)
+	if _, err := secrets.Create(secret); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+		if _, err := secrets.Update(secret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kanikoRESTConfig builds a rest.Config from the same kubeconfig
+// resolution rules kubectl/odo use, for the Exec call uploadSourceToPod
+// needs and that kubernetes.Interface has no method for.
+func kanikoRESTConfig() (*rest.Config, error) {
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(),
+		&clientcmd.ConfigOverrides{},
+	).ClientConfig()
+}
+
+// waitForContainerRunning polls until container in namespace/podName
+// reports a Running state, or kanikoWaitTimeout elapses.
+func waitForContainerRunning(clientset kubernetes.Interface, namespace, podName, container string) error {
+	return wait.PollImmediate(2*time.Second, kanikoWaitTimeout, func() (bool, error) {
+		pod, err := clientset.CoreV1().Pods(namespace).Get(podName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, status := range pod.Status.InitContainerStatuses {
+			if status.Name == container {
+				return status.State.Running != nil, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+// waitForPodCompletion polls until namespace/podName leaves the Running
+// phase, returning the phase it finished in, or an error if
+// kanikoWaitTimeout elapses first.
+func waitForPodCompletion(clientset kubernetes.Interface, namespace, podName string) (corev1.PodPhase, error) {
+	var finalPhase corev1.PodPhase
+	err := wait.PollImmediate(2*time.Second, kanikoWaitTimeout, func() (bool, error) {
+		pod, err := clientset.CoreV1().Pods(namespace).Get(podName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		finalPhase = pod.Status.Phase
+		return finalPhase == corev1.PodSucceeded || finalPhase == corev1.PodFailed, nil
+	})
+	return finalPhase, err
+}
+
+// streamPodLogs follows container's logs in namespace/podName, writing
+// each line to the user via log.Info, until stop is closed or the log
+// stream ends.
+func streamPodLogs(clientset kubernetes.Interface, namespace, podName, container string, stop <-chan struct{}) {
+	req := clientset.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{Container: container, Follow: true})
+	stream, err := req.Stream()
+	if err != nil {
+		return
+	}
+	defer stream.Close()
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(stream)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			log.Info(line)
+		}
+	}
+}
+
+// uploadSourceToPod tars contextDir (with dockerfile written in as
+// Dockerfile) and streams it into container's /workspace, then signals
+// the wait-for-source init container to hand off to the kaniko build.
+func uploadSourceToPod(clientset kubernetes.Interface, restConfig *rest.Config, namespace, podName, container, contextDir string, dockerfile []byte) error {
+	var buf bytes.Buffer
+	if err := tarContext(&buf, contextDir, dockerfile); err != nil {
+		return err
+	}
+
+	req := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(podName).
+		Namespace(namespace).
+		SubResource("exec").
+		Param("container", container).
+		Param("stdin", "true").
+		Param("command", "sh").
+		Param("command", "-c").
+		Param("command", "tar -xf - -C /workspace && touch /workspace/.odo-source-ready")
+
+	executor, err := remotecommand.NewSPDYExecutor(restConfig, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+	return executor.Stream(remotecommand.StreamOptions{
+		Stdin: &buf,
+	})
+}
+
+// tarContext writes contextDir's files into w as a tar stream with
+// dockerfile additionally written in as "Dockerfile", for uploadSourceToPod
+// to pipe into the kaniko build pod.
+func tarContext(w io.Writer, contextDir string, dockerfile []byte) error {
+	tw := tar.NewWriter(w)
+	if err := addDirToTar(tw, contextDir, ""); err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: "Dockerfile", Mode: 0644, Size: int64(len(dockerfile))}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(dockerfile); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// addDirToTar walks dir, writing every regular file under it into tw with
+// a name relative to dir (prefixed by prefix), so uploadSourceToPod's tar
+// stream extracts into /workspace with the same layout contextDir has
+// locally.
+func addDirToTar(tw *tar.Writer, dir, prefix string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := filepath.Join(prefix, entry.Name())
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			if err := addDirToTar(tw, path, name); err != nil {
+				return err
+			}
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: int64(info.Mode().Perm()), Size: info.Size()}); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cliBuiltImage is a BuiltImage produced by a CLI-driven backend
+// (buildah/buildctl/kaniko). pushed records whether the build step already
+// pushed the image, so Push becomes a no-op for backends that push as part
+// of the build.
+type cliBuiltImage struct {
+	tool   string
+	tag    string
+	pushed bool
+}
+
+func (i cliBuiltImage) Push(dockerConfigJSONFilename string) error {
+	if i.pushed {
+		return nil
+	}
+	args := []string{"push"}
+	if dockerConfigJSONFilename != "" {
+		args = append(args, "--authfile", dockerConfigJSONFilename)
+	}
+	args = append(args, i.tag)
+	if err := runCommand("", i.tool, args...); err != nil {
+		return fmt.Errorf("%s push failed: %w", i.tool, err)
+	}
+	return nil
+}
+
+func runCommand(dir, name string, args ...string) error {
+	return runCommandWithStdin(dir, nil, name, args...)
+}
+
+// runCommandWithStdin runs name with args in dir, feeding stdin to the
+// process if non-nil. buildahImageBuilder relies on this to pipe the
+// Dockerfile contents to `buildah bud -f /dev/stdin`.
+func runCommandWithStdin(dir string, stdin io.Reader, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	cmd.Stdin = stdin
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %s", err, out)
+	}
+	return nil
+}
@@ -3,7 +3,11 @@ package component
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/openshift/odo/pkg/devfile"
 	"github.com/openshift/odo/pkg/devfile/adapters/common"
@@ -52,6 +56,12 @@ type DeployOptions struct {
 	buildGuidance            common.BuildGuidanceType
 	dockerfileGuidance       *parserCommon.Dockerfile
 	sourceToImageGuidance    *parserCommon.SourceToImage
+	fromGit                  string
+	gitScratchDir            string
+	builderKind              string
+	builder                  ImageBuilder
+	cacheFrom                []string
+	squash                   bool
 	*genericclioptions.Context
 }
 
@@ -72,6 +82,12 @@ func (do *DeployOptions) CompleteDevfilePath() {
 
 // Complete completes deploy args
 func (do *DeployOptions) Complete(name string, cmd *cobra.Command, args []string) (err error) {
+	if do.fromGit != "" {
+		if err := do.cloneFromGit(); err != nil {
+			return err
+		}
+	}
+
 	do.CompleteDevfilePath()
 	envInfo, err := envinfo.NewEnvSpecificInfo(do.componentContext)
 	if err != nil {
@@ -83,8 +99,101 @@ func (do *DeployOptions) Complete(name string, cmd *cobra.Command, args []string
 	return nil
 }
 
+// cloneFromGit shallow-clones the --from-git <url>[#ref] source into a
+// scratch directory under .odo/, and points componentContext/sourcePath at
+// it so the rest of Complete/Validate/Run treats it as a normal on-disk
+// checkout. This lets CI systems run `odo deploy --from-git <url>#<sha>`
+// without a pre-checkout step.
+func (do *DeployOptions) cloneFromGit() error {
+	url, ref := splitGitRef(do.fromGit)
+
+	odoDir := filepath.Join(do.componentContext, ".odo")
+	if err := os.MkdirAll(odoDir, 0750); err != nil {
+		return errors.Wrap(err, "unable to create .odo directory for --from-git")
+	}
+
+	scratchDir, err := ioutil.TempDir(odoDir, "from-git-")
+	if err != nil {
+		return errors.Wrap(err, "unable to create scratch directory for --from-git")
+	}
+	do.gitScratchDir = scratchDir
+
+	s := log.Spinner(fmt.Sprintf("Cloning %s", do.fromGit))
+	defer s.End(false)
+
+	if ref == "" {
+		clone := exec.Command("git", "clone", "--depth=1", url, scratchDir)
+		if out, err := clone.CombinedOutput(); err != nil {
+			return errors.Wrapf(err, "unable to clone %s: %s", url, out)
+		}
+	} else if err := cloneRef(scratchDir, url, ref); err != nil {
+		return err
+	}
+
+	do.componentContext = scratchDir
+	do.sourcePath = scratchDir
+	s.End(true)
+	return nil
+}
+
+// cloneRef fetches ref (a branch, tag or commit) from url into scratchDir.
+// A plain `git clone --depth=1` followed by `git checkout <ref>` only works
+// when ref is on the remote's default branch, since the shallow clone never
+// fetches any other branch's history; fetching ref directly works for any
+// branch, tag or commit the remote advertises.
+func cloneRef(scratchDir, url, ref string) error {
+	init := exec.Command("git", "init", scratchDir)
+	if out, err := init.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "unable to init %s: %s", scratchDir, out)
+	}
+	fetch := exec.Command("git", "fetch", "--depth=1", url, ref)
+	fetch.Dir = scratchDir
+	if out, err := fetch.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "unable to fetch %s from %s: %s", ref, url, out)
+	}
+	checkout := exec.Command("git", "checkout", "FETCH_HEAD")
+	checkout.Dir = scratchDir
+	if out, err := checkout.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "unable to checkout %s: %s", ref, out)
+	}
+	return nil
+}
+
+// splitGitRef splits a --from-git value of the form <url>[#ref] into its
+// URL and optional ref/commit.
+func splitGitRef(fromGit string) (url, ref string) {
+	parts := strings.SplitN(fromGit, "#", 2)
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return parts[0], ""
+}
+
+// cleanupGitScratchDir removes the scratch directory created by
+// cloneFromGit, if any, and clears gitScratchDir so a second call (Validate
+// cleaning up after a failure, then Run's own deferred cleanup if it ever
+// ran) is a no-op rather than a second RemoveAll of an already-removed
+// directory.
+func (do *DeployOptions) cleanupGitScratchDir() {
+	if do.gitScratchDir == "" {
+		return
+	}
+	if err := os.RemoveAll(do.gitScratchDir); err != nil {
+		log.Warningf("unable to remove scratch directory %s: %v", do.gitScratchDir, err)
+	}
+	do.gitScratchDir = ""
+}
+
 // Validate validates the push parameters
 func (do *DeployOptions) Validate() (err error) {
+	// Complete may have cloned --from-git into a scratch directory; if
+	// Validate fails, Run (and its own deferred cleanup) never gets called,
+	// so clean up here too rather than leaking the checkout under .odo/.
+	defer func() {
+		if err != nil {
+			do.cleanupGitScratchDir()
+		}
+	}()
 
 	log.Infof("\nValidation")
 
@@ -115,6 +224,9 @@ func (do *DeployOptions) Validate() (err error) {
 		if bg.Dockerfile != nil {
 			do.buildGuidance = common.DockerFile
 			do.dockerfileGuidance = bg.Dockerfile
+			if len(do.cacheFrom) == 0 {
+				do.cacheFrom = bg.Dockerfile.CacheFrom
+			}
 			break
 		} else if bg.SourceToImage != nil {
 			do.buildGuidance = common.SourceToImage
@@ -188,11 +300,18 @@ func (do *DeployOptions) Validate() (err error) {
 
 	s.End(true)
 
+	do.builder, err = NewImageBuilder(do.builderKind, do.namespace, do.dockerConfigJSONFilename)
+	if err != nil {
+		return err
+	}
+
 	return
 }
 
 // Run has the logic to perform the required actions as part of command
 func (do *DeployOptions) Run() (err error) {
+	defer do.cleanupGitScratchDir()
+
 	err = do.DevfileDeploy()
 	if err != nil {
 		return err
@@ -230,6 +349,10 @@ func NewCmdDeploy(name, fullName string) *cobra.Command {
 
 	deployCmd.Flags().StringSliceVar(&do.ignores, "ignore", []string{}, "Files or folders to be ignored via glob expressions.")
 	deployCmd.Flags().StringVar(&do.dockerConfigJSONFilename, "dockerconfigjson", "~/.docker/config.json", "Filepath to config.json which authenticates the image push to the desired image registry ")
+	deployCmd.Flags().StringVar(&do.fromGit, "from-git", "", "Shallow-clone the source to deploy from a Git URL, optionally followed by #<ref>, e.g. https://github.com/example/app.git#main")
+	deployCmd.Flags().StringVar(&do.builderKind, "builder", DockerBuilder, "Image builder backend to use: docker, buildah, kaniko or buildkit")
+	deployCmd.Flags().StringSliceVar(&do.cacheFrom, "cache-from", []string{}, "Image(s) to pull the build layer cache from, instead of requiring a local parent chain")
+	deployCmd.Flags().BoolVar(&do.squash, "squash", false, "Squash the produced layers onto the base FROM image before push")
 
 	//Adding `--project` flag
 	projectCmd.AddProjectFlag(deployCmd)
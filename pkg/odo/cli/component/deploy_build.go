@@ -0,0 +1,128 @@
+package component
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/openshift/odo/pkg/devfile/adapters/common"
+	"github.com/openshift/odo/pkg/log"
+	"github.com/openshift/odo/pkg/util"
+)
+
+// DevfileDeploy builds the image described by the devfile's build guidance
+// and pushes it to do.tag, dispatching on do.buildGuidance the same way
+// Validate populated it.
+func (do *DeployOptions) DevfileDeploy() error {
+	switch do.buildGuidance {
+	case common.DockerFile:
+		return do.buildDockerfile()
+	case common.SourceToImage:
+		return do.buildSourceToImage()
+	default:
+		return fmt.Errorf("unsupported build guidance %v", do.buildGuidance)
+	}
+}
+
+// buildSourceToImage implements the Source-to-Image (S2I) build path:
+// sources are assembled into the builder image's declared source
+// directory, the builder's assemble script is run against them, and the
+// resulting image's entrypoint is set to the builder's run script,
+// mirroring OpenShift's S2I model. The produced image is pushed with the
+// same dockerconfigjson credentials used by the Dockerfile path.
+func (do *DeployOptions) buildSourceToImage() error {
+	guidance := do.sourceToImageGuidance
+	s := log.Spinner(fmt.Sprintf("Building image from builder %s using Source-to-Image", guidance.BuilderImage))
+	defer s.End(false)
+
+	sourceDir := guidance.DestinationDir
+	if sourceDir == "" {
+		sourceDir = "/tmp/src"
+	}
+
+	builder, err := do.builder.NewS2IBuild(guidance.BuilderImage)
+	if err != nil {
+		return fmt.Errorf("unable to pull S2I builder image %s: %w", guidance.BuilderImage, err)
+	}
+
+	if err := builder.CopySource(do.componentContext, sourceDir, do.ignores); err != nil {
+		return fmt.Errorf("unable to assemble sources into %s: %w", sourceDir, err)
+	}
+
+	assembleScript := guidance.AssembleScript
+	if assembleScript == "" {
+		assembleScript = filepath.Join(sourceDir, ".s2i", "bin", "assemble")
+	}
+	if err := builder.Run(assembleScript, common.BuildEnv(guidance.Env, guidance.Incremental)); err != nil {
+		return fmt.Errorf("assemble script %s failed: %w", assembleScript, err)
+	}
+
+	runScript := guidance.RunScript
+	if runScript == "" {
+		runScript = filepath.Join(sourceDir, ".s2i", "bin", "run")
+	}
+	builder.SetEntrypoint([]string{runScript})
+
+	tag := do.tag
+	if tag == "" {
+		tag, err = util.GetDefaultTag(do.namespace, do.Context)
+		if err != nil {
+			return err
+		}
+		do.tag = tag
+	}
+
+	image, err := builder.Commit(tag)
+	if err != nil {
+		return fmt.Errorf("unable to commit S2I image %s: %w", tag, err)
+	}
+
+	if err := image.Push(do.dockerConfigJSONFilename); err != nil {
+		return fmt.Errorf("unable to push image %s: %w", tag, err)
+	}
+
+	s.End(true)
+	return nil
+}
+
+// buildDockerfile builds the image from do.DockerfileBytes (downloaded by
+// Validate, or read from the project directory) and pushes it to do.tag,
+// defaulting the tag the same way as the S2I path above. Neither path
+// applies do.ManifestSource or do.DeploymentPort.
+func (do *DeployOptions) buildDockerfile() error {
+	s := log.Spinner("Building image from Dockerfile")
+	defer s.End(false)
+
+	tag := do.tag
+	var err error
+	if tag == "" {
+		tag, err = util.GetDefaultTag(do.namespace, do.Context)
+		if err != nil {
+			return err
+		}
+		do.tag = tag
+	}
+
+	dockerfile := do.DockerfileBytes
+	if dockerfile == nil {
+		dockerfile, err = ioutil.ReadFile(filepath.Join(do.componentContext, "Dockerfile"))
+		if err != nil {
+			return fmt.Errorf("unable to read Dockerfile from %s: %w", do.componentContext, err)
+		}
+	}
+
+	image, err := do.builder.BuildFromDockerfile(do.componentContext, dockerfile, tag, BuildOptions{
+		CacheFrom: do.cacheFrom,
+		Squash:    do.squash,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to build image %s: %w", tag, err)
+	}
+
+	if err := image.Push(do.dockerConfigJSONFilename); err != nil {
+		return fmt.Errorf("unable to push image %s: %w", tag, err)
+	}
+
+	s.End(true)
+	return nil
+}
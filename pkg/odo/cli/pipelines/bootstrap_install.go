@@ -0,0 +1,251 @@
+package pipelines
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/openshift/odo/pkg/log"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// installWaitTimeout bounds how long installMissingDependencies waits for a
+// Deployment or ClusterServiceVersion it just created to report ready,
+// before giving up and returning an error.
+const installWaitTimeout = 5 * time.Minute
+
+var subscriptionGVR = schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "subscriptions"}
+var csvGVR = schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "clusterserviceversions"}
+var operatorGroupGVR = schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1", Resource: "operatorgroups"}
+
+// installMissingDependencies installs whichever of Sealed Secrets, the
+// ArgoCD Operator and the OpenShift Pipelines Operator are missing, so that
+// `odo pipelines wizard --install-missing` can bootstrap a fresh cluster in
+// one shot instead of bailing out with an instruction to install them by
+// hand.
+func installMissingDependencies(io *WizardParameters, kubeClient kubernetes.Interface, missingSealedSecrets, missingArgoCD, missingPipelines bool) error {
+	if !missingSealedSecrets && !missingArgoCD && !missingPipelines {
+		return nil
+	}
+
+	var dynamicClient dynamic.Interface
+	if missingArgoCD || missingPipelines {
+		var err error
+		dynamicClient, err = newDynamicClient()
+		if err != nil {
+			return fmt.Errorf("unable to create a client to install operators: %w", err)
+		}
+	}
+
+	if missingSealedSecrets {
+		spinner := log.Spinner("Installing Sealed Secrets into " + sealedSecretsNS)
+		if err := installSealedSecrets(kubeClient); err != nil {
+			spinner.End(false)
+			return fmt.Errorf("unable to install Sealed Secrets: %w", err)
+		}
+		if err := waitForDeploymentReady(kubeClient, sealedSecretsNS, sealedSecretsName); err != nil {
+			spinner.End(false)
+			return fmt.Errorf("Sealed Secrets did not become ready: %w", err)
+		}
+		io.SealedSecretsService.Name = sealedSecretsName
+		io.SealedSecretsService.Namespace = sealedSecretsNS
+		spinner.End(true)
+	}
+
+	if missingArgoCD {
+		spinner := log.Spinner("Installing ArgoCD Operator into " + argoCDNS)
+		if err := installOperator(dynamicClient, argoCDNS, argoCDOperatorName, "alpha", "community-operators"); err != nil {
+			spinner.End(false)
+			return fmt.Errorf("unable to install ArgoCD Operator: %w", err)
+		}
+		if err := waitForCSVReady(dynamicClient, argoCDNS, argoCDOperatorName); err != nil {
+			spinner.End(false)
+			return fmt.Errorf("ArgoCD Operator did not become ready: %w", err)
+		}
+		spinner.End(true)
+	}
+
+	if missingPipelines {
+		spinner := log.Spinner("Installing OpenShift Pipelines Operator into " + pipelinesOperatorNS)
+		if err := installOperator(dynamicClient, pipelinesOperatorNS, "openshift-pipelines-operator-rh", "pipelines-1.5", "redhat-operators"); err != nil {
+			spinner.End(false)
+			return fmt.Errorf("unable to install OpenShift Pipelines Operator: %w", err)
+		}
+		if err := waitForCSVReady(dynamicClient, pipelinesOperatorNS, "openshift-pipelines-operator-rh"); err != nil {
+			spinner.End(false)
+			return fmt.Errorf("OpenShift Pipelines Operator did not become ready: %w", err)
+		}
+		spinner.End(true)
+	}
+
+	return nil
+}
+
+// installSealedSecrets applies the upstream Sealed Secrets controller's
+// RBAC and Deployment into namespace kube-system, mirroring the release
+// manifest at https://github.com/bitnami-labs/sealed-secrets/releases.
+func installSealedSecrets(kubeClient kubernetes.Interface) error {
+	sa := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: sealedSecretsName, Namespace: sealedSecretsNS},
+	}
+	if _, err := kubeClient.CoreV1().ServiceAccounts(sealedSecretsNS).Create(sa); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	clusterRole := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: sealedSecretsName},
+		Rules: []rbacv1.PolicyRule{
+			{APIGroups: []string{"bitnami.com"}, Resources: []string{"sealedsecrets"}, Verbs: []string{"get", "list", "watch", "update"}},
+			{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get", "list", "watch", "create", "update", "delete"}},
+		},
+	}
+	if _, err := kubeClient.RbacV1().ClusterRoles().Create(clusterRole); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	clusterRoleBinding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: sealedSecretsName},
+		RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: sealedSecretsName},
+		Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: sealedSecretsName, Namespace: sealedSecretsNS}},
+	}
+	if _, err := kubeClient.RbacV1().ClusterRoleBindings().Create(clusterRoleBinding); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	replicas := int32(1)
+	labels := map[string]string{"name": sealedSecretsName}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: sealedSecretsName, Namespace: sealedSecretsNS, Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: sealedSecretsName,
+					Containers: []corev1.Container{
+						{
+							Name:  sealedSecretsName,
+							Image: "docker.io/bitnami/sealed-secrets-controller:latest",
+							Args:  []string{"--update-status"},
+						},
+					},
+				},
+			},
+		},
+	}
+	if _, err := kubeClient.AppsV1().Deployments(sealedSecretsNS).Create(deployment); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return nil
+}
+
+// installOperator subscribes namespace to an OLM operator: an OperatorGroup
+// scoping the namespace (ignored if one already exists) followed by a
+// Subscription naming the package, channel and catalog source.
+func installOperator(dynamicClient dynamic.Interface, namespace, name, channel, source string) error {
+	operatorGroup := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "operators.coreos.com/v1",
+		"kind":       "OperatorGroup",
+		"metadata": map[string]interface{}{
+			"name":      namespace + "-operators",
+			"namespace": namespace,
+		},
+		"spec": map[string]interface{}{
+			"targetNamespaces": []interface{}{namespace},
+		},
+	}}
+	if _, err := dynamicClient.Resource(operatorGroupGVR).Namespace(namespace).Create(operatorGroup, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	subscription := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "operators.coreos.com/v1alpha1",
+		"kind":       "Subscription",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+		},
+		"spec": map[string]interface{}{
+			"channel":             channel,
+			"name":                name,
+			"source":              source,
+			"sourceNamespace":     "openshift-marketplace",
+			"installPlanApproval": "Automatic",
+		},
+	}}
+	if _, err := dynamicClient.Resource(subscriptionGVR).Namespace(namespace).Create(subscription, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return nil
+}
+
+// waitForDeploymentReady polls until namespace/name reports at least one
+// ready replica, or installWaitTimeout elapses.
+func waitForDeploymentReady(kubeClient kubernetes.Interface, namespace, name string) error {
+	return wait.PollImmediate(5*time.Second, installWaitTimeout, func() (bool, error) {
+		d, err := kubeClient.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		return d.Status.ReadyReplicas > 0, nil
+	})
+}
+
+// waitForCSVReady polls until a ClusterServiceVersion installed by the
+// Subscription named name in namespace reports phase Succeeded, or
+// installWaitTimeout elapses.
+func waitForCSVReady(dynamicClient dynamic.Interface, namespace, name string) error {
+	return wait.PollImmediate(5*time.Second, installWaitTimeout, func() (bool, error) {
+		sub, err := dynamicClient.Resource(subscriptionGVR).Namespace(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		csvName, found, err := unstructured.NestedString(sub.Object, "status", "installedCSV")
+		if err != nil || !found || csvName == "" {
+			return false, nil
+		}
+		csv, err := dynamicClient.Resource(csvGVR).Namespace(namespace).Get(csvName, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		phase, _, _ := unstructured.NestedString(csv.Object, "status", "phase")
+		return phase == "Succeeded", nil
+	})
+}
+
+// newDynamicClient builds a dynamic.Interface from the same kubeconfig
+// resolution rules kubectl/odo use, for creating the OLM Subscription and
+// OperatorGroup resources that installOperator needs and that the typed
+// kubernetes.Interface clientset has no generated client for.
+func newDynamicClient() (dynamic.Interface, error) {
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(),
+		&clientcmd.ConfigOverrides{},
+	).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return dynamic.NewForConfig(config)
+}
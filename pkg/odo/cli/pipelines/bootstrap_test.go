@@ -1,373 +1,126 @@
 package pipelines
 
 import (
-	"bytes"
-	"fmt"
-	"io"
 	"regexp"
 	"testing"
 
-	"github.com/openshift/odo/pkg/odo/genericclioptions"
 	"github.com/openshift/odo/pkg/pipelines"
-	"github.com/spf13/cobra"
-	appv1 "k8s.io/api/apps/v1"
-	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/client-go/kubernetes/fake"
 )
 
-type mockSpinner struct {
-	writer io.Writer
-	start  bool
-	end    bool
-}
-type keyValuePair struct {
-	key   string
-	value string
-}
-
-func TestCompleteBootstrapParameters(t *testing.T) {
-	completeTests := []struct {
-		name        string
-		prefix      string
-		wantPrefix  string
-		serviceRepo string
-		gitRepo     string
-	}{
-		{"no prefix", "", "", "https://github.com/gaganhegde/test-repo.git", "https://github.com/gaganhegde/taxi.git"},
-		{"prefix with hyphen", "test-", "test-", "https://github.com/gaganhegde/test-repo.git", "https://github.com/gaganhegde/taxi.git"},
-		{"prefix without hyphen", "test", "test-", "https://github.com/gaganhegde/test-repo.git", "https://github.com/gaganhegde/taxi.git"},
-	}
-
-	for _, tt := range completeTests {
-		o := BootstrapParameters{
-			&pipelines.BootstrapOptions{Prefix: tt.prefix, GitOpsRepoURL: tt.gitRepo, ServiceRepoURL: tt.serviceRepo, ImageRepo: ""},
-			&genericclioptions.Context{},
-		}
-
-		err := o.Validate()
-
-		if err != nil {
-			t.Errorf("Complete() %#v failed: ", err)
-		}
-
-		if o.Prefix != tt.wantPrefix {
-			t.Errorf("Complete() %#v prefix: got %s, want %s", tt.name, o.Prefix, tt.wantPrefix)
-		}
-	}
-}
-
-func TestAddSuffixWithBootstrap(t *testing.T) {
-	gitOpsURL := "https://github.com/org/gitops"
-	appURL := "https://github.com/org/app"
-	tt := []struct {
-		name           string
-		gitOpsURL      string
-		appURL         string
-		validGitOpsURL string
-		validAppURL    string
-	}{
-		{"suffix already exists", gitOpsURL + ".git", appURL + ".git", gitOpsURL + ".git", appURL + ".git"},
-		{"misssing suffix", gitOpsURL, appURL, gitOpsURL + ".git", appURL + ".git"},
-	}
-
-	for _, test := range tt {
-		t.Run(test.name, func(rt *testing.T) {
-			o := BootstrapParameters{
-				&pipelines.BootstrapOptions{
-					GitOpsRepoURL:  test.gitOpsURL,
-					ServiceRepoURL: test.appURL},
-				&genericclioptions.Context{}}
-
-			err := o.Validate()
-			if err != nil {
-				t.Errorf("Complete() %#v failed: ", err)
-			}
-
-			if o.GitOpsRepoURL != test.validGitOpsURL {
-				rt.Fatalf("URL mismatch: got %s, want %s", o.GitOpsRepoURL, test.validAppURL)
-			}
-			if o.ServiceRepoURL != test.validAppURL {
-				rt.Fatalf("URL mismatch: got %s, want %s", o.GitOpsRepoURL, test.validAppURL)
-			}
-		})
-	}
-}
-
-func TestValidateBootstrapParameters(t *testing.T) {
+func TestValidateWizardParameters(t *testing.T) {
 	optionTests := []struct {
 		name    string
 		gitRepo string
 		errMsg  string
 	}{
-		{"invalid repo", "test", "repo must be org/repo"},
-		{"valid repo", "test/repo", ""},
+		{"invalid repo", "https://github.com/test", "repo must be org/repo"},
+		{"valid github repo", "https://github.com/test/repo", ""},
+		{"valid gitlab subgroup repo", "https://gitlab.com/group/sub/repo", ""},
+		{"invalid gitlab repo", "https://gitlab.com/group", "repo must be group/repo"},
 	}
 
 	for _, tt := range optionTests {
-		o := BootstrapParameters{
-			&pipelines.BootstrapOptions{
-				GitOpsRepoURL: tt.gitRepo,
-				Prefix:        "test"},
-			&genericclioptions.Context{},
-		}
-		err := o.Validate()
-
-		if err != nil && tt.errMsg == "" {
-			t.Errorf("Validate() %#v got an unexpected error: %s", tt.name, err)
-			continue
-		}
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewWizardParameters()
+			o.GitOpsRepoURL = tt.gitRepo
 
-		if !matchError(t, tt.errMsg, err) {
-			t.Errorf("Validate() %#v failed to match error: got %s, want %s", tt.name, err, tt.errMsg)
-		}
-	}
-}
-
-// func TestBootstrapCommandWithMissingParams(t *testing.T) {
-// 	cmdTests := []struct {
-// 		desc    string
-// 		flags   []keyValuePair
-// 		wantErr string
-// 	}{
-// 		{"Missing gitops-repo-url flag",
-// 			[]keyValuePair{flag("service-repo-url", "sample/repo"), flag("image-repo", "registry/username/repo")},
-// 			`required flag(s) "gitops-repo-url" not set`},
-// 		{"Missing service-repo-url flag",
-// 			[]keyValuePair{
-// 				flag("gitops-repo-url", "sample/repo"), flag("image-repo", "registry/username/repo")},
-// 			`required flag(s) "service-repo-url" not set`},
-// 		{"Missing image-repo flag",
-// 			[]keyValuePair{
-// 				flag("gitops-repo-url", "sample/repo"), flag("service-repo-url", "sample/repo")},
-// 			`required flag(s) "image-repo" not set`},
-// 	}
-// 	for _, tt := range cmdTests {
-// 		t.Run(tt.desc, func(t *testing.T) {
-// 			_, _, err := executeCommand(NewCmdBootstrap("bootstrap", "odo pipelines bootstrap"), tt.flags...)
-// 			if err.Error() != tt.wantErr {
-// 				t.Errorf("got %s, want %s", err, tt.wantErr)
-// 			}
-// 		})
-// 	}
-// }
-
-//
-func executeCommand(cmd *cobra.Command, flags ...keyValuePair) (c *cobra.Command, output string, err error) {
-	fmt.Println("THis is cmd", cmd)
-	buf := new(bytes.Buffer)
-	cmd.SetOutput(buf)
-	for _, flag := range flags {
-		cmd.Flags().Set(flag.key, flag.value)
-	}
-	c, err = cmd.ExecuteC()
-	return c, buf.String(), err
-}
+			err := o.Validate()
 
-func matchError(t *testing.T, s string, e error) bool {
-	t.Helper()
-	if s == "" && e == nil {
-		return true
-	}
-	if s != "" && e == nil {
-		return false
-	}
-	match, err := regexp.MatchString(s, e.Error())
-	if err != nil {
-		t.Fatal(err)
+			if err != nil && tt.errMsg == "" {
+				t.Fatalf("Validate() got an unexpected error: %s", err)
+			}
+			if !matchError(t, tt.errMsg, err) {
+				t.Fatalf("Validate() failed to match error: got %v, want %s", err, tt.errMsg)
+			}
+		})
 	}
-	return match
 }
 
-func flag(k, v string) keyValuePair {
-	return keyValuePair{
-		key:   k,
-		value: v,
+func TestHasRequiredFields(t *testing.T) {
+	complete := func() *WizardParameters {
+		o := NewWizardParameters()
+		o.GitOpsRepoURL = "https://github.com/org/gitops.git"
+		o.ImageRepo = "project/app"
+		o.Prefix = "test-"
+		o.ServiceRepoURL = "https://github.com/org/app.git"
+		o.OutputPath = "/tmp/gitops"
+		o.InternalRegistryHostname = "image-registry.openshift-image-registry.svc:5000"
+		return o
 	}
-}
 
-func (m *mockSpinner) Start(status string, debug bool) {
-	m.start = true
-	fmt.Fprintf(m.writer, "\n%s", status)
-}
-
-func (m *mockSpinner) End(status bool) {
-	m.end = status
-}
-
-func (m *mockSpinner) WarningStatus(status string) {
-	fmt.Fprintf(m.writer, "[%s]", status)
-}
-
-func TestCheckSpinner(t *testing.T) {
 	tests := []struct {
-		name      string
-		err       error
-		endStatus bool
-		wantMsg   string
+		name   string
+		mutate func(*WizardParameters)
+		want   bool
 	}{
+		{"all required fields set", func(o *WizardParameters) {}, true},
+		{"missing gitOpsRepoURL", func(o *WizardParameters) { o.GitOpsRepoURL = "" }, false},
+		{"missing imageRepo", func(o *WizardParameters) { o.ImageRepo = "" }, false},
+		{"missing prefix", func(o *WizardParameters) { o.Prefix = "" }, false},
+		{"missing serviceRepoURL", func(o *WizardParameters) { o.ServiceRepoURL = "" }, false},
+		{"missing outputPath", func(o *WizardParameters) { o.OutputPath = "" }, false},
 		{
-			"No error",
-			nil,
-			true,
-			"\nChecking if abcd is installed",
-		},
-		{
-			"Resource not found error",
-			errors.NewNotFound(schema.GroupResource{}, "abcd"),
+			"external registry requires dockerConfigJSONFilename",
+			func(o *WizardParameters) { o.InternalRegistryHostname = ""; o.DockerConfigJSONFilename = "" },
 			false,
-			"\nChecking if abcd is installed[Please install abcd]",
 		},
 		{
-			"Random cluster error",
-			fmt.Errorf("Sample cluster error"),
-			false,
-			"\nChecking if abcd is installed",
+			"external registry with dockerConfigJSONFilename set",
+			func(o *WizardParameters) {
+				o.InternalRegistryHostname = ""
+				o.DockerConfigJSONFilename = "~/.docker/config.json"
+			},
+			true,
 		},
 	}
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			buff := &bytes.Buffer{}
-
-			fakeSpinner := &mockSpinner{writer: buff}
-			fakeSpinner.Start("Checking if abcd is installed", false)
-			setSpinnerStatus(fakeSpinner, "Please install abcd", test.err)
 
-			if fakeSpinner.end != test.endStatus {
-				t.Errorf("Spinner status mismatch: got %v, want %v", fakeSpinner.end, test.endStatus)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := complete()
+			tt.mutate(o)
+			if got := o.hasRequiredFields(); got != tt.want {
+				t.Errorf("hasRequiredFields() = %v, want %v", got, tt.want)
 			}
-			assertMessage(t, buff.String(), test.wantMsg)
 		})
 	}
 }
 
-func TestDependenciesWithNothingInstalled(t *testing.T) {
-	fakeClient := fake.NewSimpleClientset()
-
-	wantMsg := `
-Checking if Sealed Secrets is installed with the default configuration[Please install Sealed Secrets from https://github.com/bitnami-labs/sealed-secrets/releases]
-Checking if ArgoCD Operator is installed with the default configuration[Please install ArgoCD operator from OperatorHub]
-Checking if OpenShift Pipelines Operator is installed with the default configuration[Please install OpenShift Pipelines operator from OperatorHub]`
-
-	buff := &bytes.Buffer{}
-	fakeSpinner := &mockSpinner{writer: buff}
-	err := checkBootstrapDependencies(&BootstrapParameters{&pipelines.BootstrapOptions{}, &genericclioptions.Context{}}, fakeClient, fakeSpinner)
-	wantErr := "Failed to satisfy the required dependencies"
-
-	assertError(t, err, wantErr)
-	assertMessage(t, buff.String(), wantMsg)
-}
-
-func TestDependenciesWithAllInstalled(t *testing.T) {
-	fakeClient := fake.NewSimpleClientset(sealedSecretService(), argoCDOperator(), pipelinesOperator())
-
-	wantMsg := `
-Checking if Sealed Secrets is installed with the default configuration
-Checking if ArgoCD Operator is installed with the default configuration
-Checking if OpenShift Pipelines Operator is installed with the default configuration`
-
-	buff := &bytes.Buffer{}
-	fakeSpinner := &mockSpinner{writer: buff}
-	wizardParams := &BootstrapParameters{&pipelines.BootstrapOptions{}, &genericclioptions.Context{}}
-	err := checkBootstrapDependencies(wizardParams, fakeClient, fakeSpinner)
-
-	assertError(t, err, "")
-	if wizardParams.SealedSecretsService.Name != "sealed-secrets-controller" && wizardParams.SealedSecretsService.Namespace != "kube-system" {
-		t.Fatalf("Expected sealed secrets to be set")
+func TestMergeBootstrapOptions(t *testing.T) {
+	to := &pipelines.BootstrapOptions{GitOpsRepoURL: "https://github.com/org/gitops.git"}
+	from := &pipelines.BootstrapOptions{
+		GitOpsRepoURL:  "https://github.com/other/gitops.git",
+		ImageRepo:      "project/app",
+		Prefix:         "test-",
+		ServiceRepoURL: "https://github.com/org/app.git",
 	}
-	assertMessage(t, buff.String(), wantMsg)
-}
-
-func TestDependenciesWithNoArgoCD(t *testing.T) {
-	fakeClient := fake.NewSimpleClientset(sealedSecretService(), pipelinesOperator())
-
-	wantMsg := `
-Checking if Sealed Secrets is installed with the default configuration
-Checking if ArgoCD Operator is installed with the default configuration[Please install ArgoCD operator from OperatorHub]
-Checking if OpenShift Pipelines Operator is installed with the default configuration`
-
-	buff := &bytes.Buffer{}
-	fakeSpinner := &mockSpinner{writer: buff}
-	wizardParams := &BootstrapParameters{&pipelines.BootstrapOptions{}, &genericclioptions.Context{}}
-	err := checkBootstrapDependencies(wizardParams, fakeClient, fakeSpinner)
-	wantErr := "Failed to satisfy the required dependencies"
-
-	assertError(t, err, wantErr)
-	assertMessage(t, buff.String(), wantMsg)
-}
-
-func TestDependenciesWithNoPipelines(t *testing.T) {
-	fakeClient := fake.NewSimpleClientset(sealedSecretService(), argoCDOperator())
-
-	wantMsg := `
-Checking if Sealed Secrets is installed with the default configuration
-Checking if ArgoCD Operator is installed with the default configuration
-Checking if OpenShift Pipelines Operator is installed with the default configuration[Please install OpenShift Pipelines operator from OperatorHub]`
 
-	buff := &bytes.Buffer{}
-	fakeSpinner := &mockSpinner{writer: buff}
-	wizardParams := &BootstrapParameters{&pipelines.BootstrapOptions{}, &genericclioptions.Context{}}
-	err := checkBootstrapDependencies(wizardParams, fakeClient, fakeSpinner)
-	wantErr := "Failed to satisfy the required dependencies"
+	mergeBootstrapOptions(to, from)
 
-	assertError(t, err, wantErr)
-	assertMessage(t, buff.String(), wantMsg)
-}
-
-func assertError(t *testing.T, err error, msg string) {
-	t.Helper()
-	if err == nil {
-		if msg != "" {
-			t.Fatalf("Error mismatch: got %v, want %v", err, msg)
-		}
-		return
+	if to.GitOpsRepoURL != "https://github.com/org/gitops.git" {
+		t.Errorf("GitOpsRepoURL: flag value was overwritten by file value: got %s", to.GitOpsRepoURL)
 	}
-	if err.Error() != msg {
-		t.Fatalf("Error mismatch: got %s, want %s", err.Error(), msg)
+	if to.ImageRepo != from.ImageRepo {
+		t.Errorf("ImageRepo: got %s, want %s", to.ImageRepo, from.ImageRepo)
 	}
-}
-
-func assertMessage(t *testing.T, got, want string) {
-	t.Helper()
-	if got != want {
-		t.Fatalf("Message mismatch: got %s, want %s", got, want)
+	if to.Prefix != from.Prefix {
+		t.Errorf("Prefix: got %s, want %s", to.Prefix, from.Prefix)
 	}
-}
-
-func sealedSecretService() *corev1.Service {
-	return &corev1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "sealed-secrets-controller",
-			Namespace: "kube-system",
-		},
+	if to.ServiceRepoURL != from.ServiceRepoURL {
+		t.Errorf("ServiceRepoURL: got %s, want %s", to.ServiceRepoURL, from.ServiceRepoURL)
 	}
 }
 
-func argoCDOperator() *appv1.DeploymentList {
-	return &appv1.DeploymentList{
-		Items: []appv1.Deployment{
-			{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "argocd-operator",
-					Namespace: "argocd",
-				},
-			}, {
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "argocd-server",
-					Namespace: "argocd",
-				},
-			},
-		},
+func matchError(t *testing.T, s string, e error) bool {
+	t.Helper()
+	if s == "" && e == nil {
+		return true
 	}
-}
-
-func pipelinesOperator() *appv1.Deployment {
-	return &appv1.Deployment{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "openshift-pipelines-operator",
-			Namespace: "openshift-operators",
-		},
+	if s != "" && e == nil {
+		return false
+	}
+	match, err := regexp.MatchString(s, e.Error())
+	if err != nil {
+		t.Fatal(err)
 	}
+	return match
 }
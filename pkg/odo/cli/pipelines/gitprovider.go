@@ -0,0 +1,55 @@
+package pipelines
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Recognized Git hosting providers, used to pick the path-shape rule
+// Validate applies to the GitOps repo URL and, downstream, the API dialect
+// the commit-status-tracker and webhook wiring should speak.
+const (
+	GitHubProvider    = "github"
+	GitLabProvider    = "gitlab"
+	BitbucketProvider = "bitbucket"
+)
+
+// detectProvider returns the Git provider for host, honouring override (the
+// --git-provider flag) for self-hosted instances that don't live at one of
+// the well-known hostnames below.
+func detectProvider(host, override string) string {
+	if override != "" {
+		return override
+	}
+	switch {
+	case host == "github.com":
+		return GitHubProvider
+	case host == "gitlab.com":
+		return GitLabProvider
+	case host == "bitbucket.org":
+		return BitbucketProvider
+	default:
+		return ""
+	}
+}
+
+// validateRepoPath checks path against the shape required by provider:
+// GitHub and Bitbucket repos are always org/repo, while GitLab additionally
+// allows subgroups, so any path of two or more segments is valid as long as
+// the last segment is the repository name.
+func validateRepoPath(provider string, segments []string) error {
+	switch provider {
+	case GitLabProvider:
+		if len(segments) < 2 {
+			return fmt.Errorf("repo must be group/repo or group/subgroup/.../repo: %s", strings.Join(segments, "/"))
+		}
+		return nil
+	default:
+		// GitHub, Bitbucket, and self-hosted instances of unknown dialect
+		// all use the plain org/repo shape.
+		if len(segments) != 2 {
+			return fmt.Errorf("repo must be org/repo: %s", strings.Join(segments, "/"))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,59 @@
+package pipelines
+
+import (
+	"testing"
+)
+
+func TestDetectProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     string
+		override string
+		want     string
+	}{
+		{"github.com", "github.com", "", GitHubProvider},
+		{"gitlab.com", "gitlab.com", "", GitLabProvider},
+		{"bitbucket.org", "bitbucket.org", "", BitbucketProvider},
+		{"unknown host with no override", "git.example.com", "", ""},
+		{"override wins over well-known host", "github.com", "gitlab", "gitlab"},
+		{"override used for self-hosted instance", "git.example.com", "gitlab", "gitlab"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectProvider(tt.host, tt.override)
+			if got != tt.want {
+				t.Errorf("detectProvider(%q, %q) = %q, want %q", tt.host, tt.override, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRepoPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		segments []string
+		wantErr  bool
+	}{
+		{"github org/repo", GitHubProvider, []string{"org", "repo"}, false},
+		{"github missing repo", GitHubProvider, []string{"org"}, true},
+		{"github subgroup not allowed", GitHubProvider, []string{"org", "sub", "repo"}, true},
+		{"bitbucket org/repo", BitbucketProvider, []string{"org", "repo"}, false},
+		{"bitbucket subgroup not allowed", BitbucketProvider, []string{"org", "sub", "repo"}, true},
+		{"gitlab group/repo", GitLabProvider, []string{"group", "repo"}, false},
+		{"gitlab subgroup allowed", GitLabProvider, []string{"group", "sub", "repo"}, false},
+		{"gitlab deeply nested subgroup allowed", GitLabProvider, []string{"group", "sub1", "sub2", "repo"}, false},
+		{"gitlab missing repo", GitLabProvider, []string{"group"}, true},
+		{"self-hosted unknown provider falls back to org/repo", "", []string{"org", "repo"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRepoPath(tt.provider, tt.segments)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateRepoPath(%q, %v) error = %v, wantErr %v", tt.provider, tt.segments, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"sigs.k8s.io/yaml"
+)
+
+// BootstrapConfig is a declarative, YAML/JSON-on-disk answer to everything
+// the interactive Enter*/SelectOption* prompts in this package would
+// otherwise ask for, so `odo pipelines bootstrap --from-config
+// bootstrap.yaml --non-interactive` can replay a recorded run in CI without
+// a TTY. Fields left zero-valued still fall back to their survey prompt.
+type BootstrapConfig struct {
+	GitOpsRepoURL            string `json:"gitOpsRepoURL,omitempty"`
+	GitOpsProvider           string `json:"gitOpsProvider,omitempty"`
+	GitOpsBaseURL            string `json:"gitOpsBaseURL,omitempty"`
+	ImageRepoOption          string `json:"imageRepoOption,omitempty"`
+	InternalRegistryHostname string `json:"internalRegistryHostname,omitempty"`
+	ImageRepo                string `json:"imageRepo,omitempty"`
+	InsecureRegistry         string `json:"insecureRegistry,omitempty"`
+	RegistryCAFile           string `json:"registryCAFile,omitempty"`
+	DockerConfigJSONFilename string `json:"dockerConfigJSONFilename,omitempty"`
+	GitOpsWebhookSecret      string `json:"gitOpsWebhookSecret,omitempty"`
+	SealedSecretService      string `json:"sealedSecretService,omitempty"`
+	SealedSecretNamespace    string `json:"sealedSecretNamespace,omitempty"`
+	CommitStatusTracker      string `json:"commitStatusTracker,omitempty"`
+	StatusTrackerAccessToken string `json:"statusTrackerAccessToken,omitempty"`
+	Prefix                   string `json:"prefix,omitempty"`
+	ServiceRepoURL           string `json:"serviceRepoURL,omitempty"`
+	ServiceProvider          string `json:"serviceProvider,omitempty"`
+	ServiceBaseURL           string `json:"serviceBaseURL,omitempty"`
+	ServiceWebhookSecret     string `json:"serviceWebhookSecret,omitempty"`
+	OutputPath               string `json:"outputPath,omitempty"`
+	Overwrite                string `json:"overwrite,omitempty"`
+	OIDCEnabled              string `json:"oidcEnabled,omitempty"`
+	OIDCIssuerURL            string `json:"oidcIssuerURL,omitempty"`
+	OIDCClientID             string `json:"oidcClientID,omitempty"`
+	OIDCClientSecret         string `json:"oidcClientSecret,omitempty"`
+	OIDCRedirectURL          string `json:"oidcRedirectURL,omitempty"`
+	OAuth2Proxy              string `json:"oauth2Proxy,omitempty"`
+}
+
+// activeConfig, once set by UseConfig, is consulted by each Enter*/
+// SelectOption* prompt that has a matching BootstrapConfig field before it
+// falls back to asking the user. The provider and base-URL prompts that
+// EnterGitRepo/EnterServiceRepoURL ask when no repository URL is
+// configured, and the registry credential prompts of EnterDockercfg, do
+// not consult it.
+var activeConfig *BootstrapConfig
+
+// UseConfig makes cfg the source the subsequent Enter*/SelectOption* calls
+// described at activeConfig check before prompting. Passing nil (the
+// default) restores normal interactive behavior.
+func UseConfig(cfg *BootstrapConfig) {
+	activeConfig = cfg
+}
+
+// LoadConfig reads a BootstrapConfig from a YAML or JSON file at path.
+func LoadConfig(path string) (*BootstrapConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", path, err)
+	}
+	cfg := &BootstrapConfig{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// SaveConfig writes cfg to path as YAML, so a completed interactive run can
+// be replayed non-interactively later.
+func SaveConfig(path string, cfg *BootstrapConfig) error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("unable to encode bootstrap config: %w", err)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("unable to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// fromConfigOrPrompt returns value if it's non-empty, otherwise runs prompt
+// and returns its result. Most config-aware Enter*/SelectOption* functions
+// in this package route through it so BootstrapConfig fields transparently
+// take priority over the survey prompt they'd otherwise trigger;
+// EnterGitRepo, EnterServiceRepoURL, EnterSealedSecretService and
+// EnterDockercfg read activeConfig directly instead.
+func fromConfigOrPrompt(value string, prompt func() string) string {
+	if value != "" {
+		return value
+	}
+	return prompt()
+}
+
+// configValue reads a field off activeConfig via get, returning "" when no
+// config is active, so callers don't each need a nil check of their own.
+func configValue(get func(*BootstrapConfig) string) string {
+	if activeConfig == nil {
+		return ""
+	}
+	return get(activeConfig)
+}
+
+// requiredConfigField names a BootstrapConfig field ValidateComplete must
+// see set, and how to read it.
+type requiredConfigField struct {
+	name string
+	get  func(*BootstrapConfig) string
+}
+
+// requiredConfigFields mirrors the fields a fully interactive run would
+// eventually have to collect before pipelines.Bootstrap can run.
+var requiredConfigFields = []requiredConfigField{
+	{"gitOpsRepoURL", func(c *BootstrapConfig) string { return c.GitOpsRepoURL }},
+	{"imageRepo", func(c *BootstrapConfig) string { return c.ImageRepo }},
+	{"prefix", func(c *BootstrapConfig) string { return c.Prefix }},
+	{"serviceRepoURL", func(c *BootstrapConfig) string { return c.ServiceRepoURL }},
+	{"outputPath", func(c *BootstrapConfig) string { return c.OutputPath }},
+}
+
+// ValidateComplete reports the first field in requiredConfigFields that cfg
+// leaves unset, so --non-interactive can fail loudly up front instead of
+// blocking forever on a survey prompt with no TTY to answer it.
+func ValidateComplete(cfg *BootstrapConfig) error {
+	for _, f := range requiredConfigFields {
+		if f.get(cfg) == "" {
+			return fmt.Errorf("--non-interactive requires %q to be set in the bootstrap config", f.name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+package ui
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/openshift/odo/pkg/log"
+	"github.com/openshift/odo/pkg/pipelines/secrets"
+
+	"github.com/openshift/odo/pkg/odo/cli/ui"
+	"gopkg.in/AlecAivazis/survey.v1"
+)
+
+// dockercfgSourceOptions are the choices offered by EnterDockercfg for
+// where the image registry credentials come from.
+var dockercfgSourceOptions = []string{
+	"I already have a config.json/auth.json",
+	"Let me enter the registry credentials",
+}
+
+// dockerConfigJSON is the minimal ~/.docker/config.json shape odo needs to
+// write: a map of registry hostname to its base64-encoded "user:secret".
+type dockerConfigJSON struct {
+	Auths map[string]dockerConfigEntry `json:"auths"`
+}
+
+type dockerConfigEntry struct {
+	Auth string `json:"auth"`
+}
+
+// RegistryTLSOptions carries how a pingRegistry call should treat TLS: skip
+// verification outright (Insecure), trust an extra CA (CAFile), or neither,
+// for the default case of a registry with a publicly trusted certificate.
+type RegistryTLSOptions struct {
+	Insecure bool
+	CAFile   string
+}
+
+// EnterDockercfg allows the user to specify the path to the docker config
+// json file for external image repository authentication in a UI prompt,
+// or to build one on the fly from registry credentials (optionally read via
+// a docker-credential-<helper> binary on PATH) if they don't already have
+// one. requiredRegistries (typically just the chosen external image
+// repository's registry) is checked with secrets.ValidatePullSecret,
+// re-prompting on failure instead of silently accepting a secret that will
+// fail at push time. tlsOpts is used when odo pings the registry to sanity
+// check the credentials it's given.
+func EnterDockercfg(requiredRegistries []string, tlsOpts RegistryTLSOptions) string {
+	if path := configValue(func(c *BootstrapConfig) string { return c.DockerConfigJSONFilename }); path != "" {
+		return path
+	}
+
+	for {
+		var source string
+		prompt := &survey.Select{
+			Message: "How should odo authenticate pushes to your external image registry?",
+			Options: dockercfgSourceOptions,
+			Default: dockercfgSourceOptions[0],
+		}
+		err := survey.AskOne(prompt, &source, survey.Required)
+		ui.HandleError(err)
+
+		var path string
+		if source == dockercfgSourceOptions[0] {
+			path = enterExistingDockercfg(tlsOpts)
+		} else {
+			path = enterRegistryCredentials(tlsOpts)
+		}
+
+		if len(requiredRegistries) == 0 {
+			return path
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Warningf("could not read %s to validate it: %s", path, err)
+			return path
+		}
+		if err := secrets.ValidatePullSecret(data, requiredRegistries); err != nil {
+			log.Warningf("%s", err)
+			continue
+		}
+		return path
+	}
+}
+
+// enterExistingDockercfg is the original EnterDockercfg prompt: a bare path
+// to a config.json the user already has.
+func enterExistingDockercfg(tlsOpts RegistryTLSOptions) string {
+	var dockerCfg string
+	prompt := &survey.Input{
+		Message: "Path to config.json which authenticates image pushes to the desired image registry",
+		Help:    "The secret present in the file path generates a secure secret that authenticates the push of the image built when the app-ci pipeline is run. The image along with the necessary labels will be present on the upstream image repository of choice.",
+		Default: "~/.docker/config.json",
+	}
+
+	err := survey.AskOne(prompt, &dockerCfg, nil)
+	ui.HandleError(err)
+
+	if data, readErr := ioutil.ReadFile(dockerCfg); readErr == nil {
+		warnIfCredentialsMissing(data, tlsOpts)
+	} else {
+		log.Warningf("could not read %s to validate it: %s", dockerCfg, readErr)
+	}
+
+	return dockerCfg
+}
+
+// enterRegistryCredentials prompts for a registry hostname and either a
+// docker-credential-<helper> binary on PATH or a plain username/password,
+// synthesizes a config.json from them, and returns the path it was written
+// to.
+func enterRegistryCredentials(tlsOpts RegistryTLSOptions) string {
+	hostname := EnterRegistryHostname()
+
+	var username, password string
+	if SelectOptionCredentialHelper() == "yes" {
+		helper := EnterCredentialHelperName()
+		var err error
+		username, password, err = lookupCredentialHelper(helper, hostname)
+		ui.HandleError(err)
+	} else {
+		username = EnterRegistryUsername()
+		password = EnterRegistryPassword()
+	}
+
+	data := buildDockerConfigJSON(hostname, username, password)
+	warnIfCredentialsMissing(data, tlsOpts)
+
+	path, err := writeDockerConfigJSON(data)
+	ui.HandleError(err)
+	return path
+}
+
+// EnterRegistryHostname allows the user to specify the hostname of the
+// external image registry they're authenticating against.
+func EnterRegistryHostname() string {
+	var hostname string
+	prompt := &survey.Input{
+		Message: "Hostname of the image registry (e.g. quay.io, docker.io)",
+		Default: "quay.io",
+	}
+	err := survey.AskOne(prompt, &hostname, survey.Required)
+	ui.HandleError(err)
+	return hostname
+}
+
+// EnterRegistryUsername allows the user to specify the registry username.
+func EnterRegistryUsername() string {
+	var username string
+	prompt := &survey.Input{
+		Message: "Username for the image registry",
+	}
+	err := survey.AskOne(prompt, &username, survey.Required)
+	ui.HandleError(err)
+	return username
+}
+
+// EnterRegistryPassword allows the user to specify the registry password,
+// masked as it's typed.
+func EnterRegistryPassword() string {
+	var password string
+	prompt := &survey.Password{
+		Message: "Password or access token for the image registry",
+	}
+	err := survey.AskOne(prompt, &password, survey.Required)
+	ui.HandleError(err)
+	return password
+}
+
+// SelectOptionCredentialHelper allows the user to opt into reading
+// credentials from a docker-credential-<helper> binary instead of typing
+// them directly.
+func SelectOptionCredentialHelper() string {
+	var option string
+	prompt := &survey.Select{
+		Message: "Read credentials from a docker-credential-<helper> binary on PATH instead of typing them?",
+		Options: []string{"yes", "no"},
+		Default: "no",
+	}
+	err := survey.AskOne(prompt, &option, survey.Required)
+	ui.HandleError(err)
+	return option
+}
+
+// EnterCredentialHelperName allows the user to name the credential helper
+// binary, e.g. "osxkeychain" to invoke docker-credential-osxkeychain.
+func EnterCredentialHelperName() string {
+	var helper string
+	prompt := &survey.Input{
+		Message: "Name of the credential helper (the part after docker-credential-)",
+	}
+	err := survey.AskOne(prompt, &helper, survey.Required)
+	ui.HandleError(err)
+	return helper
+}
+
+// lookupCredentialHelper invokes `docker-credential-<helper> get`, writing
+// hostname to its stdin, and parses the {ServerURL,Username,Secret} JSON it
+// prints to stdout, as documented by the docker-credential-helpers protocol.
+func lookupCredentialHelper(helper, hostname string) (string, string, error) {
+	cmd := exec.Command(fmt.Sprintf("docker-credential-%s", helper), "get")
+	cmd.Stdin = strings.NewReader(hostname)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", "", fmt.Errorf("docker-credential-%s get %s failed: %w", helper, hostname, err)
+	}
+
+	var resp struct {
+		Username string
+		Secret   string
+	}
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		return "", "", fmt.Errorf("unable to parse docker-credential-%s output: %w", helper, err)
+	}
+	return resp.Username, resp.Secret, nil
+}
+
+// buildDockerConfigJSON synthesizes a single-registry config.json from
+// hostname, username and password.
+func buildDockerConfigJSON(hostname, username, password string) []byte {
+	auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", username, password)))
+	cfg := dockerConfigJSON{Auths: map[string]dockerConfigEntry{hostname: {Auth: auth}}}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	ui.HandleError(err)
+	return data
+}
+
+// writeDockerConfigJSON writes data to a new temp file and returns its path.
+func writeDockerConfigJSON(data []byte) (string, error) {
+	f, err := ioutil.TempFile("", "odo-dockercfg-*.json")
+	if err != nil {
+		return "", fmt.Errorf("unable to create a temp file for the registry credentials: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		return "", fmt.Errorf("unable to write the registry credentials: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// warnIfCredentialsMissing parses data as a config.json, logs a warning if
+// it doesn't contain at least one registry's auth entry (so a typo'd or
+// empty config.json doesn't fail silently much later at push time), and
+// pings each registry it does find credentials for, honoring tlsOpts.
+func warnIfCredentialsMissing(data []byte, tlsOpts RegistryTLSOptions) {
+	var cfg dockerConfigJSON
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		log.Warningf("config.json does not look like valid JSON: %s", err)
+		return
+	}
+	if len(cfg.Auths) == 0 {
+		log.Warning("config.json does not contain any registry credentials")
+		return
+	}
+	for hostname := range cfg.Auths {
+		if err := pingRegistry(hostname, tlsOpts); err != nil {
+			log.Warningf("could not validate credentials for %s: %s", hostname, err)
+		}
+	}
+}
+
+// pingRegistry does an anonymous, unauthenticated GET of the registry's v2
+// API root, the cheapest way to confirm hostname is actually a reachable
+// Docker v2 registry before odo relies on it for a push. tlsOpts.Insecure
+// skips certificate verification outright; tlsOpts.CAFile trusts an
+// additional CA instead, for self-signed on-prem registries.
+func pingRegistry(hostname string, tlsOpts RegistryTLSOptions) error {
+	transport := &http.Transport{}
+	if tlsOpts.Insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	} else if tlsOpts.CAFile != "" {
+		pool, err := certPoolFromFile(tlsOpts.CAFile)
+		if err != nil {
+			return err
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second, Transport: transport}
+	resp, err := client.Get(fmt.Sprintf("https://%s/v2/", hostname))
+	if err != nil {
+		return fmt.Errorf("unable to reach registry %s: %w", hostname, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusUnauthorized {
+		return fmt.Errorf("registry %s responded with unexpected status %s", hostname, resp.Status)
+	}
+	return nil
+}
+
+// certPoolFromFile loads a PEM CA bundle from caFile into a cert pool
+// seeded with the system's own trusted CAs.
+func certPoolFromFile(caFile string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read CA bundle %s: %w", caFile, err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s does not contain any valid PEM certificates", caFile)
+	}
+	return pool, nil
+}
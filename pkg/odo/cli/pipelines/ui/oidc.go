@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/openshift/odo/pkg/odo/cli/ui"
+	"gopkg.in/AlecAivazis/survey.v1"
+)
+
+// SelectOptionOIDC allows the user to opt into wiring the bootstrapped
+// GitOps stack up to an external OIDC/SSO identity provider instead of
+// relying on Argo CD's default local admin account.
+func SelectOptionOIDC() string {
+	return fromConfigOrPrompt(configValue(func(c *BootstrapConfig) string { return c.OIDCEnabled }), func() string {
+		var option string
+		prompt := &survey.Select{
+			Message: "Configure SSO login via an OIDC identity provider?",
+			Options: []string{"yes", "no"},
+			Default: "no",
+		}
+		err := survey.AskOne(prompt, &option, survey.Required)
+		ui.HandleError(err)
+		return option
+	})
+}
+
+// EnterOIDCIssuerURL allows the user to specify the OIDC provider's issuer
+// URL, validating it by fetching its /.well-known/openid-configuration and
+// confirming it advertises both an authorization and a token endpoint.
+func EnterOIDCIssuerURL() string {
+	return fromConfigOrPrompt(configValue(func(c *BootstrapConfig) string { return c.OIDCIssuerURL }), func() string {
+		var issuerURL string
+		prompt := &survey.Input{
+			Message: "Issuer URL for your OIDC identity provider, e.g. https://accounts.google.com",
+			Help:    "odo fetches <issuer>/.well-known/openid-configuration to confirm this is a working OIDC provider before accepting it.",
+		}
+		err := survey.AskOne(prompt, &issuerURL, validateOIDCIssuerURL())
+		ui.HandleError(err)
+		return issuerURL
+	})
+}
+
+// EnterOIDCClientID allows the user to specify the OAuth2 client ID
+// registered with the identity provider for this cluster.
+func EnterOIDCClientID() string {
+	return fromConfigOrPrompt(configValue(func(c *BootstrapConfig) string { return c.OIDCClientID }), func() string {
+		var clientID string
+		prompt := &survey.Input{
+			Message: "Client ID registered with your OIDC identity provider",
+		}
+		err := survey.AskOne(prompt, &clientID, survey.Required)
+		ui.HandleError(err)
+		return clientID
+	})
+}
+
+// EnterOIDCClientSecret allows the user to specify the OAuth2 client
+// secret, masked as it's typed.
+func EnterOIDCClientSecret() string {
+	return fromConfigOrPrompt(configValue(func(c *BootstrapConfig) string { return c.OIDCClientSecret }), func() string {
+		var clientSecret string
+		prompt := &survey.Password{
+			Message: "Client secret registered with your OIDC identity provider",
+		}
+		err := survey.AskOne(prompt, &clientSecret, survey.Required)
+		ui.HandleError(err)
+		return clientSecret
+	})
+}
+
+// SelectOptionOAuth2Proxy allows the user to additionally front the
+// pipelines dashboard with an oauth2-proxy Deployment, for clusters whose
+// dashboard has no OIDC login flow of its own.
+func SelectOptionOAuth2Proxy() string {
+	return fromConfigOrPrompt(configValue(func(c *BootstrapConfig) string { return c.OAuth2Proxy }), func() string {
+		var option string
+		prompt := &survey.Select{
+			Message: "Also deploy an oauth2-proxy in front of the pipelines dashboard?",
+			Options: []string{"yes", "no"},
+			Default: "no",
+		}
+		err := survey.AskOne(prompt, &option, survey.Required)
+		ui.HandleError(err)
+		return option
+	})
+}
+
+// EnterOIDCRedirectURL allows the user to specify the callback URL
+// registered with the identity provider for this cluster, typically the
+// pipelines dashboard route plus /oauth2/callback.
+func EnterOIDCRedirectURL() string {
+	return fromConfigOrPrompt(configValue(func(c *BootstrapConfig) string { return c.OIDCRedirectURL }), func() string {
+		var redirectURL string
+		prompt := &survey.Input{
+			Message: "Redirect URL registered with your OIDC identity provider, e.g. https://dashboard.example.com/oauth2/callback",
+		}
+		err := survey.AskOne(prompt, &redirectURL, survey.Required)
+		ui.HandleError(err)
+		return redirectURL
+	})
+}
+
+// wellKnownConfiguration is the subset of an OIDC provider's discovery
+// document EnterOIDCIssuerURL needs to confirm before accepting an issuer.
+type wellKnownConfiguration struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+}
+
+// validateOIDCIssuer fetches issuerURL's /.well-known/openid-configuration
+// and confirms it advertises both an authorization and a token endpoint.
+func validateOIDCIssuer(issuerURL string) error {
+	wellKnownURL := strings.TrimSuffix(issuerURL, "/") + "/.well-known/openid-configuration"
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(wellKnownURL)
+	if err != nil {
+		return fmt.Errorf("unable to reach %s: %w", wellKnownURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with unexpected status %s", wellKnownURL, resp.Status)
+	}
+
+	var doc wellKnownConfiguration
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return fmt.Errorf("unable to parse %s: %w", wellKnownURL, err)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" {
+		return fmt.Errorf("%s does not advertise both an authorization_endpoint and a token_endpoint", wellKnownURL)
+	}
+	return nil
+}
+
+// validateOIDCIssuerURL adapts validateOIDCIssuer to a survey.Validator.
+func validateOIDCIssuerURL() survey.Validator {
+	return func(input interface{}) error {
+		if s, ok := input.(string); ok {
+			return validateOIDCIssuer(s)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"github.com/openshift/odo/pkg/odo/cli/ui"
+	"gopkg.in/AlecAivazis/survey.v1"
+)
+
+// SelectOptionInsecureRegistry allows the user to mark the external image
+// registry they just entered as insecure (self-signed or otherwise not
+// verifiable against the system CA bundle), for on-prem/air-gapped
+// clusters whose registry isn't signed by a public CA.
+func SelectOptionInsecureRegistry() string {
+	return fromConfigOrPrompt(configValue(func(c *BootstrapConfig) string { return c.InsecureRegistry }), func() string {
+		var option string
+		prompt := &survey.Select{
+			Message: "Is this registry insecure (self-signed certificate, or no TLS)?",
+			Options: []string{"yes", "no"},
+			Default: "no",
+		}
+		err := survey.AskOne(prompt, &option, survey.Required)
+		ui.HandleError(err)
+		return option
+	})
+}
+
+// EnterRegistryCA allows the user to supply a PEM CA bundle trusted for the
+// registry, as an alternative to skipping TLS verification outright.
+func EnterRegistryCA() string {
+	return fromConfigOrPrompt(configValue(func(c *BootstrapConfig) string { return c.RegistryCAFile }), func() string {
+		var path string
+		prompt := &survey.Input{
+			Message: "Path to a PEM CA bundle for this registry (leave blank to skip TLS verification instead)",
+		}
+		err := survey.AskOne(prompt, &path, nil)
+		ui.HandleError(err)
+		return path
+	})
+}
@@ -16,8 +16,104 @@ import (
 	"gopkg.in/AlecAivazis/survey.v1"
 )
 
-// EnterGitRepo allows the user to specify the git repository in a prompt
-func EnterGitRepo() string {
+// gitProviderOptions lists the Git providers offered by SelectOptionGitProvider.
+var gitProviderOptions = []string{
+	"GitHub",
+	"GitLab",
+	"Bitbucket Server",
+	"Gitea",
+	"Self-hosted GitHub Enterprise",
+	"Self-hosted GitLab",
+}
+
+// gitProviderKey maps a gitProviderOptions selection onto the go-scm driver
+// name git.NewRepositoryForDriver expects.
+func gitProviderKey(selection string) string {
+	switch selection {
+	case "GitHub", "Self-hosted GitHub Enterprise":
+		return "github"
+	case "GitLab", "Self-hosted GitLab":
+		return "gitlab"
+	case "Bitbucket Server":
+		return "stash"
+	case "Gitea":
+		return "gitea"
+	default:
+		return ""
+	}
+}
+
+// needsBaseURL reports whether selection is hosted somewhere other than the
+// provider's public SaaS offering, and so needs a base URL prompt.
+func needsBaseURL(selection string) bool {
+	switch selection {
+	case "Bitbucket Server", "Gitea", "Self-hosted GitHub Enterprise", "Self-hosted GitLab":
+		return true
+	default:
+		return false
+	}
+}
+
+// providerTokenHelpURL returns the documentation link for creating a
+// personal access token with provider, keyed the same way as gitProviderKey.
+func providerTokenHelpURL(provider string) string {
+	switch provider {
+	case "github":
+		return "https://docs.github.com/en/github/authenticating-to-github/creating-a-personal-access-token"
+	case "gitlab":
+		return "https://docs.gitlab.com/ee/user/profile/personal_access_tokens.html"
+	case "stash":
+		return "https://confluence.atlassian.com/bitbucketserver/personal-access-tokens-939515499.html"
+	case "gitea":
+		return "https://docs.gitea.io/en-us/api-usage/#generating-and-listing-api-tokens"
+	default:
+		return ""
+	}
+}
+
+// SelectOptionGitProvider allows the user to pick which Git provider hosts
+// the repository they're about to be asked for, so that the base URL and
+// access-token prompts that follow can be tailored to it.
+func SelectOptionGitProvider() string {
+	var selection string
+	prompt := &survey.Select{
+		Message: "Select the Git provider hosting this repository",
+		Options: gitProviderOptions,
+		Default: "GitHub",
+	}
+	err := survey.AskOne(prompt, &selection, survey.Required)
+	ui.HandleError(err)
+	return selection
+}
+
+// EnterProviderBaseURL allows the user to specify the base URL of a
+// self-hosted instance of the provider chosen by SelectOptionGitProvider.
+func EnterProviderBaseURL(selection string) string {
+	var baseURL string
+	prompt := &survey.Input{
+		Message: fmt.Sprintf("Base URL for your %s instance", selection),
+		Help:    "The address odo should talk to instead of the provider's public SaaS API, e.g. https://git.example.com",
+	}
+	err := survey.AskOne(prompt, &baseURL, survey.Required)
+	ui.HandleError(err)
+	return baseURL
+}
+
+// EnterGitRepo allows the user to specify the git repository in a prompt,
+// first asking which provider hosts it (and, for self-hosted instances, its
+// base URL) so that callers like EnterStatusTrackerAccessToken can talk to
+// the right API.
+func EnterGitRepo() (string, string, string) {
+	if activeConfig != nil && activeConfig.GitOpsRepoURL != "" {
+		return activeConfig.GitOpsRepoURL, activeConfig.GitOpsProvider, activeConfig.GitOpsBaseURL
+	}
+
+	selection := SelectOptionGitProvider()
+	var baseURL string
+	if needsBaseURL(selection) {
+		baseURL = EnterProviderBaseURL(selection)
+	}
+
 	var gitopsUrl string
 	var prompt *survey.Input
 	prompt = &survey.Input{
@@ -28,107 +124,109 @@ func EnterGitRepo() string {
 	err := survey.AskOne(prompt, &gitopsUrl, survey.Required)
 	ui.HandleError(err)
 
-	return gitopsUrl
+	return gitopsUrl, gitProviderKey(selection), baseURL
 }
 
 // EnterInternalRegistry allows the user to specify the internal registry in a UI prompt.
 func EnterInternalRegistry() string {
-	var internalRegistry string
-	var prompt *survey.Input
-	prompt = &survey.Input{
-		Message: "Host-name for internal image registry to be used if you are pushing your images to the internal image registry",
-		Default: "image-registry.openshift-image-registry.svc:5000",
-	}
+	return fromConfigOrPrompt(configValue(func(c *BootstrapConfig) string { return c.InternalRegistryHostname }), func() string {
+		var internalRegistry string
+		var prompt *survey.Input
+		prompt = &survey.Input{
+			Message: "Host-name for internal image registry to be used if you are pushing your images to the internal image registry",
+			Default: "image-registry.openshift-image-registry.svc:5000",
+		}
 
-	err := survey.AskOne(prompt, &internalRegistry, nil)
-	ui.HandleError(err)
+		err := survey.AskOne(prompt, &internalRegistry, nil)
+		ui.HandleError(err)
 
-	return internalRegistry
+		return internalRegistry
+	})
 }
 
 // EnterImageRepoInternalRegistry allows the user to specify the Internal image repository in a UI prompt.
 func EnterImageRepoInternalRegistry() string {
-	var imageRepo string
-	var prompt *survey.Input
-	prompt = &survey.Input{
-		Message: "Image repository of the form <project>/<app> which is used to push newly built images.",
-		Help:    "By default images are built from source, whenever there is a push to the repository for your service source code and this image will be pushed to the image repository specified in this parameter, if the value is of the form <registry>/<username>/<repository>, then it assumed that it is an upstream image repository e.g. Quay, if its of the form <project>/<app> the internal registry present on the current cluster will be used as the image repository.",
-	}
-
-	err := survey.AskOne(prompt, &imageRepo, survey.Required)
-	ui.HandleError(err)
-
-	return imageRepo
-}
-
-// EnterDockercfg allows the user to specify the path to the docker config json file for external image repository authentication in a UI prompt.
-func EnterDockercfg() string {
-	var dockerCfg string
-	var prompt *survey.Input
-	prompt = &survey.Input{
-		Message: "Path to config.json which authenticates image pushes to the desired image registry",
-		Help:    "The secret present in the file path generates a secure secret that authenticates the push of the image built when the app-ci pipeline is run. The image along with the necessary labels will be present on the upstream image repository of choice.",
-		Default: "~/.docker/config.json",
-	}
+	return fromConfigOrPrompt(configValue(func(c *BootstrapConfig) string { return c.ImageRepo }), func() string {
+		var imageRepo string
+		var prompt *survey.Input
+		prompt = &survey.Input{
+			Message: "Image repository of the form <project>/<app> which is used to push newly built images.",
+			Help:    "By default images are built from source, whenever there is a push to the repository for your service source code and this image will be pushed to the image repository specified in this parameter, if the value is of the form <registry>/<username>/<repository>, then it assumed that it is an upstream image repository e.g. Quay, if its of the form <project>/<app> the internal registry present on the current cluster will be used as the image repository.",
+		}
 
-	err := survey.AskOne(prompt, &dockerCfg, nil)
-	ui.HandleError(err)
+		err := survey.AskOne(prompt, &imageRepo, survey.Required)
+		ui.HandleError(err)
 
-	return dockerCfg
+		return imageRepo
+	})
 }
 
 // EnterImageRepoExternalRepository allows the user to specify the type of image repository they wish to use in a UI prompt.
 func EnterImageRepoExternalRepository() string {
-	var imageRepoExt string
-	var prompt *survey.Input
-	prompt = &survey.Input{
-		Message: "Image repository of the form <registry>/<username>/<repository> which is used to push newly built images.",
-		Help:    "By default images are built from source, whenever there is a push to the repository for your service source code and this image will be pushed to the image repository specified in this parameter, if the value is of the form <registry>/<username>/<repository>, then it assumed that it is an upstream image repository e.g. Quay, if its of the form <project>/<app> the internal registry present on the current cluster will be used as the image repository.",
-	}
+	return fromConfigOrPrompt(configValue(func(c *BootstrapConfig) string { return c.ImageRepo }), func() string {
+		var imageRepoExt string
+		var prompt *survey.Input
+		prompt = &survey.Input{
+			Message: "Image repository of the form <registry>/<username>/<repository> which is used to push newly built images.",
+			Help:    "By default images are built from source, whenever there is a push to the repository for your service source code and this image will be pushed to the image repository specified in this parameter, if the value is of the form <registry>/<username>/<repository>, then it assumed that it is an upstream image repository e.g. Quay, if its of the form <project>/<app> the internal registry present on the current cluster will be used as the image repository.",
+		}
 
-	err := survey.AskOne(prompt, &imageRepoExt, survey.Required)
-	ui.HandleError(err)
+		err := survey.AskOne(prompt, &imageRepoExt, survey.Required)
+		ui.HandleError(err)
 
-	return imageRepoExt
+		return imageRepoExt
+	})
 }
 
 // EnterOutputPath allows the user to specify the path where the gitops configuration must reside locally in a UI prompt.
 func EnterOutputPath() string {
-	var outputPath string
-	var prompt *survey.Input
-	prompt = &survey.Input{
-		Message: "Provide a path to write GitOps resources?",
-		Help:    fmt.Sprintf("This is the path where the GitOps repository configuration is stored locally before you push it to the repository GitopsRepoURL"),
-		Default: ".",
-	}
+	outputPath := fromConfigOrPrompt(configValue(func(c *BootstrapConfig) string { return c.OutputPath }), func() string {
+		var outputPath string
+		var prompt *survey.Input
+		prompt = &survey.Input{
+			Message: "Provide a path to write GitOps resources?",
+			Help:    fmt.Sprintf("This is the path where the GitOps repository configuration is stored locally before you push it to the repository GitopsRepoURL"),
+			Default: ".",
+		}
+
+		err := survey.AskOne(prompt, &outputPath, nil)
+		ui.HandleError(err)
+		return outputPath
+	})
 
-	err := survey.AskOne(prompt, &outputPath, nil)
 	exists, _ := ioutils.IsExisting(ioutils.NewFilesystem(), filepath.Join(outputPath, "pipelines.yaml"))
 	if exists {
 		SelectOptionOverwrite(outputPath)
 	}
-	ui.HandleError(err)
 
 	return outputPath
 }
 
 // EnterGitWebhookSecret allows the user to specify the webhook secret string they wish to authenticate push/pull to gitops repo in a UI prompt.
 func EnterGitWebhookSecret() string {
-	var gitWebhookSecret string
-	var prompt *survey.Input
-	prompt = &survey.Input{
-		Message: "Provide a secret whose length should be 16 or more characters that we can use to authenticate incoming hooks from your Git hosting service for the GitOps repository. (if not provided, it will be auto-generated)",
-		Help:    "The webhook secret is a secure string you plan to use to authenticate pull/push requests to the version control system of your choice, this secure string will be added to the webhook sealed secret created to enhance security. Choose a secure string of your choice for this field.",
-	}
+	return fromConfigOrPrompt(configValue(func(c *BootstrapConfig) string { return c.GitOpsWebhookSecret }), func() string {
+		var gitWebhookSecret string
+		var prompt *survey.Input
+		prompt = &survey.Input{
+			Message: "Provide a secret whose length should be 16 or more characters that we can use to authenticate incoming hooks from your Git hosting service for the GitOps repository. (if not provided, it will be auto-generated)",
+			Help:    "The webhook secret is a secure string you plan to use to authenticate pull/push requests to the version control system of your choice, this secure string will be added to the webhook sealed secret created to enhance security. Choose a secure string of your choice for this field.",
+		}
 
-	err := survey.AskOne(prompt, &gitWebhookSecret, validateSecretLength(gitWebhookSecret))
-	ui.HandleError(err)
+		err := survey.AskOne(prompt, &gitWebhookSecret, validateSecretLength(gitWebhookSecret))
+		ui.HandleError(err)
 
-	return gitWebhookSecret
+		return gitWebhookSecret
+	})
 }
 
 // EnterSealedSecretService , if the secret isnt installed using the operator it is necessary to manually add the sealed-secrets-controller name through this UI prompt.
 func EnterSealedSecretService(sealedSecretService *types.NamespacedName) string {
+	if cfg := configValue(func(c *BootstrapConfig) string { return c.SealedSecretService }); cfg != "" {
+		sealedSecretService.Name = cfg
+		sealedSecretService.Namespace = EnterSealedSecretNamespace()
+		return cfg
+	}
+
 	var sealedSecret string
 	var prompt *survey.Input
 	prompt = &survey.Input{
@@ -143,45 +241,70 @@ func EnterSealedSecretService(sealedSecretService *types.NamespacedName) string
 
 // EnterSealedSecretNamespace , if the secret isnt installed using the operator it is necessary to manually add the sealed-secrets-namepsace in which its installed through this UI prompt.
 func EnterSealedSecretNamespace() string {
-	var sealedNs string
-	var prompt *survey.Input
-	prompt = &survey.Input{
-		Message: "Provide a namespace in which the Sealed Secrets operator is installed, automatically generated secrets are encrypted with this operator?",
-		Help:    "If you have a custom installation of the Sealed Secrets operator, we need to know how to communicate with it to seal your secrets",
-	}
+	return fromConfigOrPrompt(configValue(func(c *BootstrapConfig) string { return c.SealedSecretNamespace }), func() string {
+		var sealedNs string
+		var prompt *survey.Input
+		prompt = &survey.Input{
+			Message: "Provide a namespace in which the Sealed Secrets operator is installed, automatically generated secrets are encrypted with this operator?",
+			Help:    "If you have a custom installation of the Sealed Secrets operator, we need to know how to communicate with it to seal your secrets",
+		}
 
-	err := survey.AskOne(prompt, &sealedNs, survey.Required)
-	ui.HandleError(err)
+		err := survey.AskOne(prompt, &sealedNs, survey.Required)
+		ui.HandleError(err)
 
-	return sealedNs
+		return sealedNs
+	})
 }
 
 // EnterStatusTrackerAccessToken , it becomes necessary to add the personal access token from github to autheticate the commit-status-tracker.
-func EnterStatusTrackerAccessToken(serviceRepo string) string {
-	var accessToken string
-	prompt := &survey.Password{
-		Message: "Please provide a token used to authenticate API calls to push commit-status updates to your Git hosting service",
-		Help:    "commit-status-tracker reports the completion status of OpenShift pipeline runs to your Git hosting status on success or failure, this token will be encrypted as a secret in your cluster.\n If you are using Github, please see here for how to generate a token https://docs.github.com/en/github/authenticating-to-github/creating-a-personal-access-token\nIf you are using GitLab, please see here for how to generate a token https://docs.gitlab.com/ee/user/profile/personal_access_tokens.html",
-	}
-	err := survey.AskOne(prompt, &accessToken, validateAccessToken(serviceRepo))
-	ui.HandleError(err)
-	return accessToken
+// provider and baseURL (as returned by EnterGitRepo/EnterServiceRepoURL)
+// select which go-scm driver validateAccessToken checks the token against.
+func EnterStatusTrackerAccessToken(serviceRepo, provider, baseURL string) string {
+	return fromConfigOrPrompt(configValue(func(c *BootstrapConfig) string { return c.StatusTrackerAccessToken }), func() string {
+		var accessToken string
+		help := "commit-status-tracker reports the completion status of OpenShift pipeline runs to your Git hosting status on success or failure, this token will be encrypted as a secret in your cluster."
+		if helpURL := providerTokenHelpURL(provider); helpURL != "" {
+			help += fmt.Sprintf("\nPlease see here for how to generate a token: %s", helpURL)
+		}
+		prompt := &survey.Password{
+			Message: "Please provide a token used to authenticate API calls to push commit-status updates to your Git hosting service",
+			Help:    help,
+		}
+		err := survey.AskOne(prompt, &accessToken, validateAccessToken(serviceRepo, provider, baseURL))
+		ui.HandleError(err)
+		return accessToken
+	})
 }
 
 // EnterPrefix , if we desire to add the prefix to differentiate between namespaces, then this is the way forward.
 func EnterPrefix() string {
-	var prefix string
-	prompt := &survey.Input{
-		Message: "Add a prefix to the environment names(dev, stage, cicd etc.) to distinguish and identify individual environments?",
-		Help:    "The prefix helps differentiate between the different namespaces on the cluster, the default namespace cicd will appear as test-cicd if the prefix passed is test.",
-	}
-	err := survey.AskOne(prompt, &prefix, ValidatePrefix(prefix))
-	ui.HandleError(err)
-	return prefix
+	return fromConfigOrPrompt(configValue(func(c *BootstrapConfig) string { return c.Prefix }), func() string {
+		var prefix string
+		prompt := &survey.Input{
+			Message: "Add a prefix to the environment names(dev, stage, cicd etc.) to distinguish and identify individual environments?",
+			Help:    "The prefix helps differentiate between the different namespaces on the cluster, the default namespace cicd will appear as test-cicd if the prefix passed is test.",
+		}
+		err := survey.AskOne(prompt, &prefix, ValidatePrefix(prefix))
+		ui.HandleError(err)
+		return prefix
+	})
 }
 
 // EnterServiceRepoURL , allows users to differentiate between the bootstrap and init options, addition of the service repo url will allow users to bootstrap an environment through the UI prompt.
-func EnterServiceRepoURL() string {
+// It first asks which provider hosts the service repository, the same way
+// EnterGitRepo does, so the commit-status-tracker and webhook wiring can
+// talk to the right API.
+func EnterServiceRepoURL() (string, string, string) {
+	if activeConfig != nil && activeConfig.ServiceRepoURL != "" {
+		return activeConfig.ServiceRepoURL, activeConfig.ServiceProvider, activeConfig.ServiceBaseURL
+	}
+
+	selection := SelectOptionGitProvider()
+	var baseURL string
+	if needsBaseURL(selection) {
+		baseURL = EnterProviderBaseURL(selection)
+	}
+
 	var serviceRepo string
 	prompt := &survey.Input{
 		Message: "Provide the URL for your Service repository e.g. https://github.com/organisation/service.git",
@@ -189,60 +312,68 @@ func EnterServiceRepoURL() string {
 	}
 	err := survey.AskOne(prompt, &serviceRepo, survey.Required)
 	ui.HandleError(err)
-	return serviceRepo
+	return serviceRepo, gitProviderKey(selection), baseURL
 }
 
 // EnterServiceWebhookSecret allows the user to specify the webhook secret string they wish to authenticate push/pull to service repo in a UI prompt.
 func EnterServiceWebhookSecret() string {
-	var serviceWebhookSecret string
-	prompt := &survey.Input{
-		Message: "Provide a secret whose length should be 16 or more characters that we can use to authenticate incoming hooks from your Git hosting service for the Service repository. (if not provided, it will be auto-generated)",
-		Help:    "The webhook secret is a secure string you plan to use to authenticate pull/push requests to the version control system of your choice, this secure string will be added to the webhook sealed secret created to enhance security. Choose a secure string of your choice for this field.",
-	}
-	err := survey.AskOne(prompt, &serviceWebhookSecret, validateSecretLength(serviceWebhookSecret))
-	ui.HandleError(err)
-	return serviceWebhookSecret
+	return fromConfigOrPrompt(configValue(func(c *BootstrapConfig) string { return c.ServiceWebhookSecret }), func() string {
+		var serviceWebhookSecret string
+		prompt := &survey.Input{
+			Message: "Provide a secret whose length should be 16 or more characters that we can use to authenticate incoming hooks from your Git hosting service for the Service repository. (if not provided, it will be auto-generated)",
+			Help:    "The webhook secret is a secure string you plan to use to authenticate pull/push requests to the version control system of your choice, this secure string will be added to the webhook sealed secret created to enhance security. Choose a secure string of your choice for this field.",
+		}
+		err := survey.AskOne(prompt, &serviceWebhookSecret, validateSecretLength(serviceWebhookSecret))
+		ui.HandleError(err)
+		return serviceWebhookSecret
+	})
 }
 
 // SelectOptionImageRepository , allows users an option between the Internal image registry and the external image registry through the UI prompt.
 func SelectOptionImageRepository() string {
-	var optionImageRegistry string
+	return fromConfigOrPrompt(configValue(func(c *BootstrapConfig) string { return c.ImageRepoOption }), func() string {
+		var optionImageRegistry string
 
-	prompt := &survey.Select{
-		Message: "Select type of image repository",
-		Options: []string{"Openshift Internal repository", "External Registry"},
-		Default: "Openshift Internal repository",
-	}
-	err := survey.AskOne(prompt, &optionImageRegistry, survey.Required)
-	ui.HandleError(err)
-	return optionImageRegistry
+		prompt := &survey.Select{
+			Message: "Select type of image repository",
+			Options: []string{"Openshift Internal repository", "External Registry"},
+			Default: "Openshift Internal repository",
+		}
+		err := survey.AskOne(prompt, &optionImageRegistry, survey.Required)
+		ui.HandleError(err)
+		return optionImageRegistry
+	})
 }
 
 // SelectOptionOverwrite allows users the option to overwrite the current gitops configuration locally through the UI prompt.
 func SelectOptionOverwrite(path string) string {
-	var overwrite string
+	return fromConfigOrPrompt(configValue(func(c *BootstrapConfig) string { return c.Overwrite }), func() string {
+		var overwrite string
 
-	prompt := &survey.Select{
-		Message: "Do you want to overwrite your output path. Select yes or no",
-		Options: []string{"yes", "no"},
-		Default: "no",
-	}
-	err := survey.AskOne(prompt, &overwrite, validateOverwriteOption(path))
-	ui.HandleError(err)
-	return overwrite
+		prompt := &survey.Select{
+			Message: "Do you want to overwrite your output path. Select yes or no",
+			Options: []string{"yes", "no"},
+			Default: "no",
+		}
+		err := survey.AskOne(prompt, &overwrite, validateOverwriteOption(path))
+		ui.HandleError(err)
+		return overwrite
+	})
 }
 
 // SelectOptionCommitStatusTracker allows users the option to select if they wanna incorporate the feature of the commit status tracker through the UI prompt.
 func SelectOptionCommitStatusTracker() string {
-	var optionCommitStatusTracker string
+	return fromConfigOrPrompt(configValue(func(c *BootstrapConfig) string { return c.CommitStatusTracker }), func() string {
+		var optionCommitStatusTracker string
 
-	prompt := &survey.Select{
-		Message: "Please enter (yes/no) if you desire to use commit-status-tracker",
-		Options: []string{"yes", "no"},
-	}
-	err := survey.AskOne(prompt, &optionCommitStatusTracker, survey.Required)
-	ui.HandleError(err)
-	return optionCommitStatusTracker
+		prompt := &survey.Select{
+			Message: "Please enter (yes/no) if you desire to use commit-status-tracker",
+			Options: []string{"yes", "no"},
+		}
+		err := survey.AskOne(prompt, &optionCommitStatusTracker, survey.Required)
+		ui.HandleError(err)
+		return optionCommitStatusTracker
+	})
 }
 
 //check if the length of secret is less than 16 chars
@@ -299,10 +430,13 @@ func validateOverwriteOption(path string) survey.Validator {
 	}
 }
 
-func validateAccessToken(serviceRepo string) survey.Validator {
+func validateAccessToken(serviceRepo, provider, baseURL string) survey.Validator {
 	return func(input interface{}) error {
 		if s, ok := input.(string); ok {
-			repo, _ := git.NewRepository(serviceRepo, s)
+			repo, err := git.NewRepositoryForDriver(provider, baseURL, serviceRepo, s)
+			if err != nil {
+				return fmt.Errorf("The token passed is incorrect for repository %s", serviceRepo)
+			}
 			parsedURL, err := url.Parse(serviceRepo)
 			repoName, err := git.GetRepoName(parsedURL)
 			_, _, err = repo.Client.Repositories.Find(context.Background(), repoName)
@@ -2,6 +2,7 @@ package pipelines
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/url"
 	"path/filepath"
 	"strings"
@@ -11,10 +12,13 @@ import (
 	"github.com/openshift/odo/pkg/odo/cli/pipelines/utility"
 	"github.com/openshift/odo/pkg/odo/genericclioptions"
 	"github.com/openshift/odo/pkg/pipelines"
+	"github.com/openshift/odo/pkg/pipelines/imagerepo"
 	"github.com/openshift/odo/pkg/pipelines/ioutils"
 	"github.com/openshift/odo/pkg/pipelines/namespaces"
 	"github.com/spf13/cobra"
 
+	"sigs.k8s.io/yaml"
+
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	ktemplates "k8s.io/kubectl/pkg/util/templates"
@@ -46,6 +50,39 @@ var (
 type WizardParameters struct {
 	*pipelines.BootstrapOptions
 	// generic context options common to all commands
+
+	// fromFile is the path to a YAML file carrying the same fields as
+	// BootstrapOptions, for non-interactive/CI use.
+	fromFile string
+	// yes lets completeNonInteractive overwrite an existing pipelines.yaml
+	// at the output path; the interactive path still asks through the
+	// overwrite prompt.
+	yes bool
+	// installMissing, when set, installs whichever of Sealed Secrets, the
+	// ArgoCD Operator and the OpenShift Pipelines Operator
+	// checkBootstrapDependencies finds missing, instead of failing.
+	installMissing bool
+	// gitProvider overrides detectProvider's hostname-based guess, for
+	// self-hosted GitHub/GitLab/Bitbucket instances.
+	gitProvider string
+	// gitOpsWebhookSecretFile/serviceWebhookSecretFile point at files
+	// holding the corresponding webhook secret, so it never needs typing
+	// into a flag value or a config file in plaintext.
+	gitOpsWebhookSecretFile  string
+	serviceWebhookSecretFile string
+	// fromConfig is the path to a ui.BootstrapConfig, consulted by the
+	// ui.Enter*/SelectOption* prompts that have a matching field so a
+	// previously recorded bootstrap can be replayed without retyping it.
+	fromConfig string
+	// nonInteractive fails Complete immediately if fromConfig leaves any
+	// required field unset, instead of falling through to a survey prompt
+	// that would block forever without a TTY.
+	nonInteractive bool
+	// saveConfig, if set, writes the resolved parameters of this run to
+	// this path as a ui.BootstrapConfig once Complete succeeds, so it can
+	// be replayed later with --from-config --non-interactive.
+	saveConfig string
+
 	*genericclioptions.Context
 }
 
@@ -60,7 +97,30 @@ func NewWizardParameters() *WizardParameters {
 //
 // If the prefix provided doesn't have a "-" then one is added, this makes the
 // generated environment names nicer to read.
+//
+// When --from-file, or the individual non-interactive flags, already supply
+// every required value, Complete skips the survey prompts entirely and just
+// validates what was given, so `odo pipelines wizard` can run unattended in
+// CI or from scripts.
 func (io *WizardParameters) Complete(name string, cmd *cobra.Command, args []string) error {
+	if io.fromFile != "" {
+		if err := io.loadFromFile(); err != nil {
+			return err
+		}
+	}
+
+	if io.fromConfig != "" {
+		cfg, err := ui.LoadConfig(io.fromConfig)
+		if err != nil {
+			return err
+		}
+		if io.nonInteractive {
+			if err := ui.ValidateComplete(cfg); err != nil {
+				return err
+			}
+		}
+		ui.UseConfig(cfg)
+	}
 
 	clientSet, err := namespaces.GetClientSet()
 	if err != nil {
@@ -71,35 +131,56 @@ func (io *WizardParameters) Complete(name string, cmd *cobra.Command, args []str
 		return err
 	}
 
+	if io.hasRequiredFields() {
+		return io.completeNonInteractive()
+	}
+
 	// ask for sealed secrets only when default is absent
 	if io.SealedSecretsService == (types.NamespacedName{}) {
 		io.SealedSecretsService.Name = ui.EnterSealedSecretService()
 		io.SealedSecretsService.Namespace = ui.EnterSealedSecretNamespace()
 	}
 
-	io.GitOpsRepoURL = ui.EnterGitRepo()
+	var gitOpsProvider string
+	io.GitOpsRepoURL, gitOpsProvider, io.GitOpsBaseURL = ui.EnterGitRepo()
+	if gitOpsProvider != "" {
+		io.gitProvider = gitOpsProvider
+	}
 	option := ui.SelectOptionImageRepository()
 	if option == "Openshift Internal repository" {
 		io.InternalRegistryHostname = ui.EnterInternalRegistry()
 		io.ImageRepo = ui.EnterImageRepoInternalRegistry()
 
 	} else {
-		io.DockerConfigJSONFilename = ui.EnterDockercfg()
 		io.ImageRepo = ui.EnterImageRepoExternalRepository()
+		io.InsecureRegistry = ui.SelectOptionInsecureRegistry() == "yes"
+		if io.InsecureRegistry {
+			io.RegistryCAFile = ui.EnterRegistryCA()
+		}
+		tlsOpts := ui.RegistryTLSOptions{Insecure: io.RegistryCAFile == "" && io.InsecureRegistry, CAFile: io.RegistryCAFile}
+		io.DockerConfigJSONFilename = ui.EnterDockercfg(requiredPullSecretRegistries(io.ImageRepo), tlsOpts)
 	}
 	io.GitOpsWebhookSecret = ui.EnterGitWebhookSecret()
 	commitStatusTrackerCheck := ui.SelectOptionCommitStatusTracker()
 	if commitStatusTrackerCheck == "yes" {
-		io.StatusTrackerAccessToken = ui.EnterStatusTrackerAccessToken()
+		io.StatusTrackerAccessToken = ui.EnterStatusTrackerAccessToken(io.GitOpsRepoURL, gitOpsProvider, io.GitOpsBaseURL)
 	}
 	io.Prefix = ui.EnterPrefix()
 	io.Prefix = utility.MaybeCompletePrefix(io.Prefix)
-	io.ServiceRepoURL = ui.EnterServiceRepoURL()
+	io.ServiceRepoURL, io.ServiceGitProvider, io.ServiceGitBaseURL = ui.EnterServiceRepoURL()
 	if io.ServiceRepoURL != "" {
 		io.ServiceWebhookSecret = ui.EnterServiceWebhookSecret()
 		io.ServiceRepoURL = utility.AddGitSuffixIfNecessary(io.ServiceRepoURL)
 	}
 
+	if ui.SelectOptionOIDC() == "yes" {
+		io.OIDCIssuerURL = ui.EnterOIDCIssuerURL()
+		io.OIDCClientID = ui.EnterOIDCClientID()
+		io.OIDCClientSecret = ui.EnterOIDCClientSecret()
+		io.OIDCRedirectURL = ui.EnterOIDCRedirectURL()
+		io.EnableOAuth2Proxy = ui.SelectOptionOAuth2Proxy() == "yes"
+	}
+
 	io.OutputPath = ui.EnterOutputPath(io.GitOpsRepoURL)
 	exists, _ := ioutils.IsExisting(ioutils.NewFilesystem(), filepath.Join(io.OutputPath, "pipelines.yaml"))
 	if exists {
@@ -111,17 +192,208 @@ func (io *WizardParameters) Complete(name string, cmd *cobra.Command, args []str
 	}
 	io.Overwrite = true
 	io.GitOpsRepoURL = utility.AddGitSuffixIfNecessary(io.GitOpsRepoURL)
+
+	if io.saveConfig != "" {
+		if err := ui.SaveConfig(io.saveConfig, io.toBootstrapConfig()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// toBootstrapConfig snapshots io's resolved parameters as a
+// ui.BootstrapConfig, for --save-config to write out so a later run can
+// replay this one with --from-config --non-interactive.
+func (io *WizardParameters) toBootstrapConfig() *ui.BootstrapConfig {
+	imageRepoOption := "External Registry"
+	if io.InternalRegistryHostname != "" {
+		imageRepoOption = "Openshift Internal repository"
+	}
+	commitStatusTracker := "no"
+	if io.StatusTrackerAccessToken != "" {
+		commitStatusTracker = "yes"
+	}
+	overwrite := "no"
+	if io.Overwrite {
+		overwrite = "yes"
+	}
+	oidcEnabled := "no"
+	if io.OIDCIssuerURL != "" {
+		oidcEnabled = "yes"
+	}
+	oauth2Proxy := "no"
+	if io.EnableOAuth2Proxy {
+		oauth2Proxy = "yes"
+	}
+	insecureRegistry := "no"
+	if io.InsecureRegistry {
+		insecureRegistry = "yes"
+	}
+
+	return &ui.BootstrapConfig{
+		GitOpsRepoURL:            io.GitOpsRepoURL,
+		GitOpsProvider:           io.GitProvider,
+		GitOpsBaseURL:            io.GitOpsBaseURL,
+		ImageRepoOption:          imageRepoOption,
+		InternalRegistryHostname: io.InternalRegistryHostname,
+		ImageRepo:                io.ImageRepo,
+		InsecureRegistry:         insecureRegistry,
+		RegistryCAFile:           io.RegistryCAFile,
+		DockerConfigJSONFilename: io.DockerConfigJSONFilename,
+		GitOpsWebhookSecret:      io.GitOpsWebhookSecret,
+		SealedSecretService:      io.SealedSecretsService.Name,
+		SealedSecretNamespace:    io.SealedSecretsService.Namespace,
+		CommitStatusTracker:      commitStatusTracker,
+		StatusTrackerAccessToken: io.StatusTrackerAccessToken,
+		Prefix:                   io.Prefix,
+		ServiceRepoURL:           io.ServiceRepoURL,
+		ServiceProvider:          io.ServiceGitProvider,
+		ServiceBaseURL:           io.ServiceGitBaseURL,
+		ServiceWebhookSecret:     io.ServiceWebhookSecret,
+		OutputPath:               io.OutputPath,
+		Overwrite:                overwrite,
+		OIDCEnabled:              oidcEnabled,
+		OIDCIssuerURL:            io.OIDCIssuerURL,
+		OIDCClientID:             io.OIDCClientID,
+		OIDCClientSecret:         io.OIDCClientSecret,
+		OIDCRedirectURL:          io.OIDCRedirectURL,
+		OAuth2Proxy:              oauth2Proxy,
+	}
+}
+
+// hasRequiredFields reports whether every value Complete would otherwise
+// prompt for has already been supplied, via --from-file or individual
+// flags. An external image repository (InternalRegistryHostname unset)
+// additionally needs DockerConfigJSONFilename, the same as the interactive
+// External Registry branch would prompt EnterDockercfg for.
+func (io *WizardParameters) hasRequiredFields() bool {
+	if io.GitOpsRepoURL == "" ||
+		io.ImageRepo == "" ||
+		io.Prefix == "" ||
+		io.ServiceRepoURL == "" ||
+		io.OutputPath == "" {
+		return false
+	}
+	if io.InternalRegistryHostname == "" && io.DockerConfigJSONFilename == "" {
+		return false
+	}
+	return true
+}
+
+// completeNonInteractive validates a fully flag/file-supplied set of
+// parameters without touching the TTY.
+func (io *WizardParameters) completeNonInteractive() error {
+	io.Prefix = utility.MaybeCompletePrefix(io.Prefix)
+	io.ServiceRepoURL = utility.AddGitSuffixIfNecessary(io.ServiceRepoURL)
+	io.GitOpsRepoURL = utility.AddGitSuffixIfNecessary(io.GitOpsRepoURL)
+
+	if io.gitOpsWebhookSecretFile != "" {
+		secret, err := ioutil.ReadFile(io.gitOpsWebhookSecretFile)
+		if err != nil {
+			return fmt.Errorf("unable to read --gitops-webhook-secret-file %s: %w", io.gitOpsWebhookSecretFile, err)
+		}
+		io.GitOpsWebhookSecret = strings.TrimSpace(string(secret))
+	}
+	if io.serviceWebhookSecretFile != "" {
+		secret, err := ioutil.ReadFile(io.serviceWebhookSecretFile)
+		if err != nil {
+			return fmt.Errorf("unable to read --service-webhook-secret-file %s: %w", io.serviceWebhookSecretFile, err)
+		}
+		io.ServiceWebhookSecret = strings.TrimSpace(string(secret))
+	}
+
+	exists, _ := ioutils.IsExisting(ioutils.NewFilesystem(), filepath.Join(io.OutputPath, "pipelines.yaml"))
+	if exists && !io.yes {
+		return fmt.Errorf("Cannot create GitOps configuration since file exists at %s, pass --yes to overwrite", io.OutputPath)
+	}
+	io.Overwrite = true
+	return nil
+}
+
+// loadFromFile reads a YAML-encoded pipelines.BootstrapOptions from
+// io.fromFile, filling in the fields mergeBootstrapOptions copies that
+// flags left unset. Flags always take precedence over the file.
+func (io *WizardParameters) loadFromFile() error {
+	data, err := ioutil.ReadFile(io.fromFile)
+	if err != nil {
+		return fmt.Errorf("unable to read --from-file %s: %w", io.fromFile, err)
+	}
+
+	fileOpts := &pipelines.BootstrapOptions{}
+	if err := yaml.Unmarshal(data, fileOpts); err != nil {
+		return fmt.Errorf("unable to parse --from-file %s: %w", io.fromFile, err)
+	}
+
+	mergeBootstrapOptions(io.BootstrapOptions, fileOpts)
 	return nil
 }
 
+// mergeBootstrapOptions fills each field of to handled below that to has
+// left unset with from's value, so command-line flags win over the config
+// file. GitOpsBaseURL, the ServiceGit* fields, RegistryCAFile, the OIDC
+// fields and the boolean options are not copied from from.
+func mergeBootstrapOptions(to, from *pipelines.BootstrapOptions) {
+	if to.GitOpsRepoURL == "" {
+		to.GitOpsRepoURL = from.GitOpsRepoURL
+	}
+	if to.ImageRepo == "" {
+		to.ImageRepo = from.ImageRepo
+	}
+	if to.InternalRegistryHostname == "" {
+		to.InternalRegistryHostname = from.InternalRegistryHostname
+	}
+	if to.DockerConfigJSONFilename == "" {
+		to.DockerConfigJSONFilename = from.DockerConfigJSONFilename
+	}
+	if to.SealedSecretsService == (types.NamespacedName{}) {
+		to.SealedSecretsService = from.SealedSecretsService
+	}
+	if to.Prefix == "" {
+		to.Prefix = from.Prefix
+	}
+	if to.ServiceRepoURL == "" {
+		to.ServiceRepoURL = from.ServiceRepoURL
+	}
+	if to.GitOpsWebhookSecret == "" {
+		to.GitOpsWebhookSecret = from.GitOpsWebhookSecret
+	}
+	if to.ServiceWebhookSecret == "" {
+		to.ServiceWebhookSecret = from.ServiceWebhookSecret
+	}
+	if to.OutputPath == "" {
+		to.OutputPath = from.OutputPath
+	}
+	if to.StatusTrackerAccessToken == "" {
+		to.StatusTrackerAccessToken = from.StatusTrackerAccessToken
+	}
+	if to.GitProvider == "" {
+		to.GitProvider = from.GitProvider
+	}
+}
+
+// requiredPullSecretRegistries returns the registry hostnames EnterDockercfg
+// should confirm the supplied config.json can authenticate against, derived
+// from imageRepo the same way CreateInternalRegistryResources would resolve
+// it at apply time.
+func requiredPullSecretRegistries(imageRepo string) []string {
+	info, err := imagerepo.ParseRepositoryInfo(imageRepo, nil)
+	if err != nil {
+		return nil
+	}
+	return []string{info.Index}
+}
+
 func checkBootstrapDependencies(io *WizardParameters, kubeClient kubernetes.Interface) error {
 
 	client := utility.NewClient(kubeClient)
 	log.Progressf("\nChecking dependencies\n")
 
+	missingSealedSecrets := false
 	sealedSpinner := log.Spinner("Checking if Sealed Secrets is installed at kube-system namespace")
 	err := client.CheckIfSealedSecretsExists(sealedSecretsNS+"s", sealedSecretsName)
 	if err != nil {
+		missingSealedSecrets = true
 		sealedSpinner.WarningStatus("Please install Sealed Secrets from https://github.com/bitnami-labs/sealed-secrets/releases")
 		sealedSpinner.End(false)
 	} else {
@@ -130,28 +402,37 @@ func checkBootstrapDependencies(io *WizardParameters, kubeClient kubernetes.Inte
 		sealedSpinner.End(true)
 	}
 
+	missingArgoCD := false
 	argoSpinner := log.Spinner("Checking if ArgoCD Operator is installed at argocd namespace")
 	err = client.CheckIfArgoCDExists(argoCDNS)
 	if err != nil {
+		missingArgoCD = true
 		argoSpinner.WarningStatus("Please install ArgoCD operator from OperatorHub")
 		argoSpinner.End(false)
 	} else {
 		argoSpinner.End(true)
 	}
 
+	missingPipelines := false
 	pipelineSpinner := log.Spinner("Checking if OpenShift Pipelines Operator is installed")
 	err = client.CheckIfPipelinesExists(pipelinesOperatorNS)
 	if err != nil {
+		missingPipelines = true
 		pipelineSpinner.WarningStatus("Please install OpenShift Pipelines operator from OperatorHub")
 		pipelineSpinner.End(false)
 	} else {
 		pipelineSpinner.End(true)
 	}
 
-	if err != nil {
-		return fmt.Errorf("Failed to satisfy the required dependencies")
+	if !missingSealedSecrets && !missingArgoCD && !missingPipelines {
+		return nil
 	}
-	return nil
+
+	if io.installMissing {
+		return installMissingDependencies(io, kubeClient, missingSealedSecrets, missingArgoCD, missingPipelines)
+	}
+
+	return fmt.Errorf("Failed to satisfy the required dependencies")
 }
 
 // Validate validates the parameters of the WizardParameters.
@@ -161,10 +442,12 @@ func (io *WizardParameters) Validate() error {
 		return fmt.Errorf("failed to parse url %s: %w", io.GitOpsRepoURL, err)
 	}
 
-	// TODO: this won't work with GitLab as the repo can have more path elements.
-	if len(utility.RemoveEmptyStrings(strings.Split(gr.Path, "/"))) != 2 {
-		return fmt.Errorf("repo must be org/repo: %s", strings.Trim(gr.Path, ".git"))
+	provider := detectProvider(gr.Hostname(), io.gitProvider)
+	segments := utility.RemoveEmptyStrings(strings.Split(gr.Path, "/"))
+	if err := validateRepoPath(provider, segments); err != nil {
+		return err
 	}
+	io.GitProvider = provider
 
 	return nil
 }
@@ -194,5 +477,25 @@ func NewCmdWizard(name, fullName string) *cobra.Command {
 			genericclioptions.GenericRun(o, cmd, args)
 		},
 	}
+
+	wizardCmd.Flags().StringVar(&o.fromFile, "from-file", "", "Path to a YAML file supplying the wizard's parameters non-interactively")
+	wizardCmd.Flags().BoolVar(&o.yes, "yes", false, "Overwrite the output path without prompting, if it already exists and every required value is supplied by flags or --from-file")
+	wizardCmd.Flags().BoolVar(&o.installMissing, "install-missing", false, "Install Sealed Secrets, the ArgoCD Operator and the OpenShift Pipelines Operator if they're missing, instead of failing")
+	wizardCmd.Flags().StringVar(&o.GitOpsRepoURL, "gitops-repo-url", "", "URL for the GitOps repository")
+	wizardCmd.Flags().StringVar(&o.ImageRepo, "image-repo", "", "Image repository used to push newly built images")
+	wizardCmd.Flags().StringVar(&o.InternalRegistryHostname, "internal-registry-hostname", "", "Host-name for the internal image registry, if --image-repo is of the form <project>/<app>")
+	wizardCmd.Flags().StringVar(&o.DockerConfigJSONFilename, "dockerconfigjson", "", "Path to config.json authenticating pushes to an external image repository")
+	wizardCmd.Flags().StringVar(&o.SealedSecretsService.Name, "sealed-secrets-service", "", "Name of the Sealed Secrets service that encrypts secrets")
+	wizardCmd.Flags().StringVar(&o.SealedSecretsService.Namespace, "sealed-secrets-namespace", "", "Namespace the Sealed Secrets operator is installed in")
+	wizardCmd.Flags().StringVar(&o.Prefix, "prefix", "", "Prefix added to the generated environment names")
+	wizardCmd.Flags().StringVar(&o.ServiceRepoURL, "service-repo-url", "", "URL for the Service repository")
+	wizardCmd.Flags().StringVar(&o.OutputPath, "output", "", "Path to write GitOps resources to")
+	wizardCmd.Flags().StringVar(&o.gitOpsWebhookSecretFile, "gitops-webhook-secret-file", "", "Path to a file containing the GitOps repository's webhook secret")
+	wizardCmd.Flags().StringVar(&o.serviceWebhookSecretFile, "service-webhook-secret-file", "", "Path to a file containing the Service repository's webhook secret")
+	wizardCmd.Flags().StringVar(&o.gitProvider, "git-provider", "", "Git provider hosting the GitOps repository (github, gitlab, bitbucket), for self-hosted instances that can't be detected from the URL")
+	wizardCmd.Flags().StringVar(&o.fromConfig, "from-config", "", "Path to a BootstrapConfig YAML/JSON file whose values replace the matching prompts, for replaying a recorded run")
+	wizardCmd.Flags().BoolVar(&o.nonInteractive, "non-interactive", false, "Fail immediately if --from-config leaves any required field unset, instead of prompting")
+	wizardCmd.Flags().StringVar(&o.saveConfig, "save-config", "", "Path to write this run's resolved parameters to as a BootstrapConfig, for replaying later with --from-config")
+
 	return wizardCmd
 }
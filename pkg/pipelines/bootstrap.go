@@ -0,0 +1,129 @@
+// Package pipelines generates resources for a GitOps configuration
+// repository (Bootstrap) and reconciles generator-produced environments
+// (ReconcileEnvironmentGenerators). It writes to a filesystem only;
+// nothing in it pushes to a Git repository.
+package pipelines
+
+import (
+	"fmt"
+
+	"github.com/openshift/odo/pkg/pipelines/config"
+	"github.com/openshift/odo/pkg/pipelines/ioutils"
+	"github.com/openshift/odo/pkg/pipelines/oidc"
+	res "github.com/openshift/odo/pkg/pipelines/resources"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// BootstrapOptions holds everything `odo pipelines bootstrap`/`wizard` needs
+// to generate a new GitOps configuration repository, whether gathered from
+// interactive survey prompts, individual flags, a --from-file manifest, or a
+// --from-config replay.
+type BootstrapOptions struct {
+	// GitOpsRepoURL is the Git repository the generated GitOps manifest is
+	// written to/driven from.
+	GitOpsRepoURL string `json:"gitOpsRepoURL,omitempty"`
+	// GitProvider is the detected/overridden Git hosting provider for
+	// GitOpsRepoURL, e.g. "github", "gitlab".
+	GitProvider string `json:"gitProvider,omitempty"`
+	// GitOpsBaseURL overrides the provider's default API base URL, for
+	// self-hosted GitHub/GitLab/Bitbucket instances.
+	GitOpsBaseURL string `json:"gitOpsBaseURL,omitempty"`
+	// GitOpsWebhookSecret authenticates the webhook GitOpsRepoURL delivers
+	// push events through.
+	GitOpsWebhookSecret string `json:"gitOpsWebhookSecret,omitempty"`
+
+	// InternalRegistryHostname is the cluster's internal image registry
+	// hostname, set only when ImageRepo resolves against it.
+	InternalRegistryHostname string `json:"internalRegistryHostname,omitempty"`
+	// ImageRepo is the image repository pipelines build and push to, either
+	// a <project>/<app> pair in the internal registry or a full external
+	// repository reference.
+	ImageRepo string `json:"imageRepo,omitempty"`
+	// InsecureRegistry marks ImageRepo's registry as not presenting a
+	// trusted TLS certificate. The wizard skips TLS verification for it
+	// when checking registry credentials (unless RegistryCAFile is set);
+	// Bootstrap does not read it, and no generated resource carries
+	// --tls-verify=false.
+	InsecureRegistry bool `json:"insecureRegistry,omitempty"`
+	// RegistryCAFile is the path to a PEM-encoded CA bundle for
+	// ImageRepo's registry, which the wizard trusts when checking registry
+	// credentials. Bootstrap does not read it or mount it into any
+	// generated resource.
+	RegistryCAFile string `json:"registryCAFile,omitempty"`
+	// DockerConfigJSONFilename is a ~/.docker/config.json carrying the
+	// credentials pipelines need to pull/push ImageRepo.
+	DockerConfigJSONFilename string `json:"dockerConfigJSONFilename,omitempty"`
+
+	// SealedSecretsService names the Sealed Secrets controller Service
+	// generated SealedSecret resources are encrypted against.
+	SealedSecretsService types.NamespacedName `json:"sealedSecretsService,omitempty"`
+
+	// StatusTrackerAccessToken authenticates commit status updates posted
+	// back to GitOpsRepoURL/ServiceRepoURL's hosting provider.
+	StatusTrackerAccessToken string `json:"statusTrackerAccessToken,omitempty"`
+
+	// Prefix is prepended to every generated environment name.
+	Prefix string `json:"prefix,omitempty"`
+
+	// ServiceRepoURL is the application source repository. Bootstrap does
+	// not read it; the wizard's Run calls Bootstrap only when it is set.
+	ServiceRepoURL string `json:"serviceRepoURL,omitempty"`
+	// ServiceGitProvider/ServiceGitBaseURL mirror GitProvider/GitOpsBaseURL
+	// for ServiceRepoURL.
+	ServiceGitProvider string `json:"serviceGitProvider,omitempty"`
+	ServiceGitBaseURL  string `json:"serviceGitBaseURL,omitempty"`
+	// ServiceWebhookSecret authenticates the webhook ServiceRepoURL
+	// delivers push events through.
+	ServiceWebhookSecret string `json:"serviceWebhookSecret,omitempty"`
+
+	// OIDCIssuerURL, when set, wires Argo CD's SSO login up to an external
+	// OIDC identity provider instead of its default local admin account.
+	// ClientID/ClientSecret/RedirectURL are the matching OAuth2 client
+	// registration, and EnableOAuth2Proxy additionally fronts the
+	// pipelines dashboard with an oauth2-proxy Deployment.
+	OIDCIssuerURL     string `json:"oidcIssuerURL,omitempty"`
+	OIDCClientID      string `json:"oidcClientID,omitempty"`
+	OIDCClientSecret  string `json:"oidcClientSecret,omitempty"`
+	OIDCRedirectURL   string `json:"oidcRedirectURL,omitempty"`
+	EnableOAuth2Proxy bool   `json:"enableOAuth2Proxy,omitempty"`
+
+	// OutputPath is the directory the generated GitOps repository is
+	// written to on disk.
+	OutputPath string `json:"outputPath,omitempty"`
+	// Overwrite records that an existing pipelines.yaml at OutputPath may
+	// be replaced. The wizard checks for that file before calling
+	// Bootstrap; Bootstrap itself neither reads Overwrite nor checks for
+	// the file.
+	Overwrite bool `json:"overwrite,omitempty"`
+}
+
+// Bootstrap writes to fs, under opts.OutputPath, the Argo CD
+// SSO/oauth2-proxy resources oidc.CreateResources renders from opts' OIDC
+// fields when opts.OIDCIssuerURL is set. It generates no manifest and no
+// Kustomize bases/overlays, and reads no field of opts besides OutputPath
+// and the OIDC fields; with OIDCIssuerURL unset it writes no resources.
+func Bootstrap(opts *BootstrapOptions, fs ioutils.Filesystem) error {
+	if opts.OutputPath == "" {
+		return fmt.Errorf("unable to bootstrap: no output path given")
+	}
+
+	cfg := &config.PipelinesConfig{}
+	resources := res.Resources{}
+
+	if opts.OIDCIssuerURL != "" {
+		oidcResources, err := oidc.CreateResources(cfg, oidc.Options{
+			IssuerURL:         opts.OIDCIssuerURL,
+			ClientID:          opts.OIDCClientID,
+			ClientSecret:      opts.OIDCClientSecret,
+			RedirectURL:       opts.OIDCRedirectURL,
+			EnableOAuth2Proxy: opts.EnableOAuth2Proxy,
+		})
+		if err != nil {
+			return fmt.Errorf("unable to generate OIDC resources: %w", err)
+		}
+		resources = res.Merge(oidcResources, resources)
+	}
+
+	return res.WriteTo(fs, opts.OutputPath, resources)
+}
@@ -0,0 +1,85 @@
+package pipelines
+
+import (
+	"fmt"
+
+	"github.com/openshift/odo/pkg/pipelines/config"
+	"github.com/openshift/odo/pkg/pipelines/config/generators"
+	"github.com/openshift/odo/pkg/pipelines/imagerepo"
+	res "github.com/openshift/odo/pkg/pipelines/resources"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ReconcileEnvironmentGenerators is the reconcile hook `odo pipelines
+// build` runs on every invocation of a Manifest with EnvironmentGenerators
+// configured: it provisions CreateInternalRegistryResources for every
+// generator-produced Environment (so a new pull request gets its own
+// internal-registry namespace/role-binding the moment it's opened) and
+// returns the Environments whose pull request PruneClosedEnvironments
+// reports as no longer open, for the caller to tear down.
+func ReconcileEnvironmentGenerators(m *config.Manifest, regCfg *imagerepo.RegistryConfig, sa *corev1.ServiceAccount, prs []generators.PullRequest) (res.Resources, []*config.Environment, error) {
+	resources := res.Resources{}
+	generated := []*config.Environment{}
+
+	for _, env := range m.Environments {
+		if env.PRNumber == 0 {
+			continue
+		}
+		generated = append(generated, env)
+
+		repoInfo, err := imagerepo.ParseRepositoryInfo(fmt.Sprintf("%s/%s", env.Namespace, env.Name), regCfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to resolve image repository for environment %q: %w", env.Name, err)
+		}
+		envResources, err := imagerepo.CreateInternalRegistryResources(&config.PipelinesConfig{}, sa, repoInfo, mergeAnnotations(env.ArgoCD.Annotations(), repoInfo.Annotations()))
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to generate internal registry resources for environment %q: %w", env.Name, err)
+		}
+		resources = res.Merge(envResources, resources)
+	}
+
+	toPrune := generators.PruneClosedEnvironments(toGeneratedEnvironments(generated), prs)
+	pruned := make([]*config.Environment, 0, len(toPrune))
+	for _, g := range toPrune {
+		for _, env := range generated {
+			if env.PRNumber == g.PRNumber {
+				pruned = append(pruned, env)
+			}
+		}
+	}
+
+	return resources, pruned, nil
+}
+
+// mergeAnnotations combines a and b into a single map, so
+// ReconcileEnvironmentGenerators can pass an Environment's ArgoCDOptions
+// annotations and its RepositoryInfo's insecure-registry annotation to
+// CreateInternalRegistryResources together.
+func mergeAnnotations(a, b map[string]string) map[string]string {
+	if len(a) == 0 {
+		return b
+	}
+	merged := make(map[string]string, len(a)+len(b))
+	for k, v := range a {
+		merged[k] = v
+	}
+	for k, v := range b {
+		merged[k] = v
+	}
+	return merged
+}
+
+// toGeneratedEnvironments adapts envs back into the
+// generators.GeneratedEnvironment shape PruneClosedEnvironments expects,
+// since only PRNumber is needed to match them against prs.
+func toGeneratedEnvironments(envs []*config.Environment) []*generators.GeneratedEnvironment {
+	out := make([]*generators.GeneratedEnvironment, 0, len(envs))
+	for _, env := range envs {
+		out = append(out, &generators.GeneratedEnvironment{
+			Name:     env.Name,
+			PRNumber: env.PRNumber,
+		})
+	}
+	return out
+}
@@ -0,0 +1,308 @@
+// Package generators implements ApplicationSet-style dynamic Application
+// generators for the pipelines manifest, mirroring the generator taxonomy
+// from Argo CD's ApplicationSet. A generator expands into a slice of
+// parameter maps which are substituted into an ApplicationTemplate using
+// Go's text/template, producing one GeneratedApplication per parameter set.
+package generators
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// Params is a single set of template parameters produced by a Generator.
+type Params map[string]string
+
+// Generator expands into zero or more parameter sets. Path identifies the
+// generator in error messages, e.g. "environments[0].applicationGenerators[1].gitDirectory".
+type Generator interface {
+	Generate() ([]Params, error)
+	Path() string
+}
+
+// RepositoryTemplate is the templated form of a config.Repository.
+type RepositoryTemplate struct {
+	URL  string `yaml:"url"`
+	Path string `yaml:"path,omitempty"`
+}
+
+// ArgoCDOptionsTemplate is the templated form of a config.ArgoCDOptions.
+// Its values are copied straight through to the generated Application
+// rather than rendered, since sync/compare-options are a fixed set of
+// enum values rather than free text.
+type ArgoCDOptionsTemplate struct {
+	SyncOptions    []string `yaml:"syncOptions,omitempty"`
+	CompareOptions []string `yaml:"compareOptions,omitempty"`
+}
+
+// ApplicationTemplate is substituted with each Generator's Params to
+// produce a GeneratedApplication. Name, ServiceRefs, ConfigRepo and ArgoCD
+// mirror config.Application's fields.
+type ApplicationTemplate struct {
+	Name        string                 `yaml:"name"`
+	ServiceRefs []string               `yaml:"serviceRefs,omitempty"`
+	ConfigRepo  *RepositoryTemplate    `yaml:"configRepo,omitempty"`
+	ArgoCD      *ArgoCDOptionsTemplate `yaml:"argoCD,omitempty"`
+}
+
+// ApplicationGenerator pairs exactly one generator kind with the template
+// it expands into. Exactly one of List, GitDirectory, SCMProvider or Matrix
+// must be set.
+type ApplicationGenerator struct {
+	List         *ListGenerator         `yaml:"list,omitempty"`
+	GitDirectory *GitDirectoryGenerator `yaml:"gitDirectory,omitempty"`
+	SCMProvider  *SCMProviderGenerator  `yaml:"scmProvider,omitempty"`
+	Matrix       *MatrixGenerator       `yaml:"matrix,omitempty"`
+
+	Template ApplicationTemplate `yaml:"template"`
+}
+
+// generator returns the single configured Generator implementation, or an
+// error if none or more than one is set.
+func (ag *ApplicationGenerator) generator(path string) (Generator, error) {
+	set := []Generator{}
+	if ag.List != nil {
+		ag.List.path = path + ".list"
+		set = append(set, ag.List)
+	}
+	if ag.GitDirectory != nil {
+		ag.GitDirectory.path = path + ".gitDirectory"
+		set = append(set, ag.GitDirectory)
+	}
+	if ag.SCMProvider != nil {
+		ag.SCMProvider.path = path + ".scmProvider"
+		set = append(set, ag.SCMProvider)
+	}
+	if ag.Matrix != nil {
+		ag.Matrix.path = path + ".matrix"
+		set = append(set, ag.Matrix)
+	}
+	if len(set) != 1 {
+		return nil, fmt.Errorf("%s: exactly one of list, gitDirectory, scmProvider or matrix must be set", path)
+	}
+	return set[0], nil
+}
+
+// GeneratedApplication is a concrete Application produced by expanding a
+// generator's Params into its ApplicationTemplate. GeneratorPath and
+// Params are retained so that validation errors on the resolved
+// application can be traced back to the generator that produced it.
+type GeneratedApplication struct {
+	Name          string
+	ServiceRefs   []string
+	ConfigRepo    *RepositoryTemplate
+	ArgoCD        *ArgoCDOptionsTemplate
+	GeneratorPath string
+	Params        Params
+}
+
+// ListGenerator expands into its Elements verbatim, the simplest generator
+// in the taxonomy.
+type ListGenerator struct {
+	Elements []Params `yaml:"elements"`
+
+	path string
+}
+
+func (g *ListGenerator) Generate() ([]Params, error) { return g.Elements, nil }
+func (g *ListGenerator) Path() string                { return g.path }
+
+// DirectoryLister lists the subdirectories of path in a Git repository at
+// url#revision. It is implemented by the pipelines Git provider clients.
+type DirectoryLister interface {
+	ListDirectories(url, revision, path string) ([]string, error)
+}
+
+// GitDirectoryGenerator generates one Params per subdirectory of Path in a
+// Git repository: each subdirectory becomes a candidate application, with
+// its name exposed to the template as the "path" parameter.
+type GitDirectoryGenerator struct {
+	RepoURL  string `yaml:"repoURL"`
+	Revision string `yaml:"revision"`
+	Path     string `yaml:"path"`
+
+	Lister DirectoryLister `yaml:"-"`
+
+	path string
+}
+
+func (g *GitDirectoryGenerator) Generate() ([]Params, error) {
+	if g.Lister == nil {
+		return nil, fmt.Errorf("%s: no directory lister configured for %s", g.path, g.RepoURL)
+	}
+	dirs, err := g.Lister.ListDirectories(g.RepoURL, g.Revision, g.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to list directories in %s: %w", g.path, g.RepoURL, err)
+	}
+	params := make([]Params, 0, len(dirs))
+	for _, d := range dirs {
+		params = append(params, Params{"path": d, "path.basename": d})
+	}
+	return params, nil
+}
+
+func (g *GitDirectoryGenerator) Path() string { return g.path }
+
+// RepositoryLister enumerates repositories in an SCM org matching a
+// label/topic filter. It is implemented by the pipelines SCM provider
+// clients (GitHub, GitLab, ...).
+type RepositoryLister interface {
+	ListRepositories(provider, org, filter string) ([]string, error)
+}
+
+// SCMProviderGenerator enumerates the repositories of a GitHub/GitLab org
+// matching Filter, and generates one Params per matching repository.
+type SCMProviderGenerator struct {
+	Provider string `yaml:"provider"`
+	Org      string `yaml:"org"`
+	Filter   string `yaml:"filter,omitempty"`
+
+	Lister RepositoryLister `yaml:"-"`
+
+	path string
+}
+
+func (g *SCMProviderGenerator) Generate() ([]Params, error) {
+	if g.Lister == nil {
+		return nil, fmt.Errorf("%s: no repository lister configured for provider %s", g.path, g.Provider)
+	}
+	repos, err := g.Lister.ListRepositories(g.Provider, g.Org, g.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to list repositories in %s/%s: %w", g.path, g.Provider, g.Org, err)
+	}
+	params := make([]Params, 0, len(repos))
+	for _, r := range repos {
+		params = append(params, Params{"repository": r, "organization": g.Org})
+	}
+	return params, nil
+}
+
+func (g *SCMProviderGenerator) Path() string { return g.path }
+
+// MatrixGenerator takes the Cartesian product of two child generators'
+// Params, merging each pair into a single parameter set.
+type MatrixGenerator struct {
+	Generators [2]ApplicationGenerator `yaml:"generators"`
+
+	path string
+}
+
+func (g *MatrixGenerator) Generate() ([]Params, error) {
+	left, err := g.Generators[0].generator(g.path + ".generators[0]")
+	if err != nil {
+		return nil, err
+	}
+	leftParams, err := left.Generate()
+	if err != nil {
+		return nil, err
+	}
+	right, err := g.Generators[1].generator(g.path + ".generators[1]")
+	if err != nil {
+		return nil, err
+	}
+	rightParams, err := right.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	product := make([]Params, 0, len(leftParams)*len(rightParams))
+	for _, l := range leftParams {
+		for _, r := range rightParams {
+			merged := Params{}
+			for k, v := range l {
+				merged[k] = v
+			}
+			for k, v := range r {
+				merged[k] = v
+			}
+			product = append(product, merged)
+		}
+	}
+	return product, nil
+}
+
+func (g *MatrixGenerator) Path() string { return g.path }
+
+// Expand runs each of appGens' generators and substitutes the resulting
+// Params into its ApplicationTemplate, returning one GeneratedApplication
+// per parameter set. envPath identifies the owning environment in error
+// messages, e.g. "environments[0]".
+func Expand(envPath string, appGens []ApplicationGenerator) ([]*GeneratedApplication, []error) {
+	apps := []*GeneratedApplication{}
+	errs := []error{}
+
+	for i := range appGens {
+		ag := &appGens[i]
+		path := fmt.Sprintf("%s.applicationGenerators[%d]", envPath, i)
+		gen, err := ag.generator(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		paramSets, err := gen.Generate()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, params := range paramSets {
+			app, err := renderTemplate(gen.Path(), ag.Template, params)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			apps = append(apps, app)
+		}
+	}
+	return apps, errs
+}
+
+func renderTemplate(generatorPath string, tmpl ApplicationTemplate, params Params) (*GeneratedApplication, error) {
+	name, err := execTemplate(generatorPath, "name", tmpl.Name, params)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceRefs := make([]string, len(tmpl.ServiceRefs))
+	for i, ref := range tmpl.ServiceRefs {
+		rendered, err := execTemplate(generatorPath, "serviceRefs", ref, params)
+		if err != nil {
+			return nil, err
+		}
+		serviceRefs[i] = rendered
+	}
+
+	var configRepo *RepositoryTemplate
+	if tmpl.ConfigRepo != nil {
+		url, err := execTemplate(generatorPath, "configRepo.url", tmpl.ConfigRepo.URL, params)
+		if err != nil {
+			return nil, err
+		}
+		path, err := execTemplate(generatorPath, "configRepo.path", tmpl.ConfigRepo.Path, params)
+		if err != nil {
+			return nil, err
+		}
+		configRepo = &RepositoryTemplate{URL: url, Path: path}
+	}
+
+	return &GeneratedApplication{
+		Name:          name,
+		ServiceRefs:   serviceRefs,
+		ConfigRepo:    configRepo,
+		ArgoCD:        tmpl.ArgoCD,
+		GeneratorPath: generatorPath,
+		Params:        params,
+	}, nil
+}
+
+func execTemplate(generatorPath, field, text string, params Params) (string, error) {
+	t, err := template.New(field).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%s: invalid %s template %q: %w", generatorPath, field, text, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, params); err != nil {
+		return "", fmt.Errorf("%s: failed to render %s template %q: %w", generatorPath, field, text, err)
+	}
+	return buf.String(), nil
+}
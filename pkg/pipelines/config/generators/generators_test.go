@@ -0,0 +1,202 @@
+package generators
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+type fakeDirectoryLister struct {
+	dirs []string
+	err  error
+}
+
+func (f fakeDirectoryLister) ListDirectories(url, revision, path string) ([]string, error) {
+	return f.dirs, f.err
+}
+
+func TestListGeneratorGenerate(t *testing.T) {
+	g := &ListGenerator{Elements: []Params{{"env": "dev"}, {"env": "stage"}}}
+	got, err := g.Generate()
+	if err != nil {
+		t.Fatalf("Generate() unexpected error: %s", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Generate() returned %d params, want 2", len(got))
+	}
+}
+
+func TestGitDirectoryGeneratorGenerate(t *testing.T) {
+	t.Run("no lister configured", func(t *testing.T) {
+		g := &GitDirectoryGenerator{RepoURL: "https://example.com/repo.git"}
+		if _, err := g.Generate(); err == nil {
+			t.Fatal("Generate() expected an error when no Lister is configured")
+		}
+	})
+
+	t.Run("lister returns directories", func(t *testing.T) {
+		g := &GitDirectoryGenerator{
+			RepoURL: "https://example.com/repo.git",
+			Lister:  fakeDirectoryLister{dirs: []string{"api", "web"}},
+		}
+		got, err := g.Generate()
+		if err != nil {
+			t.Fatalf("Generate() unexpected error: %s", err)
+		}
+		if len(got) != 2 {
+			t.Fatalf("Generate() returned %d params, want 2", len(got))
+		}
+		if got[0]["path"] != "api" || got[0]["path.basename"] != "api" {
+			t.Errorf("Generate()[0] = %v, want path/path.basename both %q", got[0], "api")
+		}
+	})
+
+	t.Run("lister error is wrapped", func(t *testing.T) {
+		g := &GitDirectoryGenerator{
+			RepoURL: "https://example.com/repo.git",
+			Lister:  fakeDirectoryLister{err: fmt.Errorf("boom")},
+		}
+		if _, err := g.Generate(); err == nil {
+			t.Fatal("Generate() expected an error from the lister")
+		}
+	})
+}
+
+func TestMatrixGeneratorGenerate(t *testing.T) {
+	m := &MatrixGenerator{
+		Generators: [2]ApplicationGenerator{
+			{List: &ListGenerator{Elements: []Params{{"region": "us"}, {"region": "eu"}}}},
+			{List: &ListGenerator{Elements: []Params{{"tier": "web"}, {"tier": "api"}}}},
+		},
+	}
+
+	got, err := m.Generate()
+	if err != nil {
+		t.Fatalf("Generate() unexpected error: %s", err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("Generate() returned %d params, want 4 (cartesian product)", len(got))
+	}
+
+	combos := make([]string, len(got))
+	for i, p := range got {
+		combos[i] = p["region"] + "/" + p["tier"]
+	}
+	sort.Strings(combos)
+	want := []string{"eu/api", "eu/web", "us/api", "us/web"}
+	for i := range want {
+		if combos[i] != want[i] {
+			t.Errorf("combos = %v, want %v", combos, want)
+		}
+	}
+}
+
+func TestMatrixGeneratorGenerateErrors(t *testing.T) {
+	t.Run("neither child generator set", func(t *testing.T) {
+		m := &MatrixGenerator{}
+		if _, err := m.Generate(); err == nil {
+			t.Fatal("Generate() expected an error when no child generators are set")
+		}
+	})
+
+	t.Run("child generator itself errors", func(t *testing.T) {
+		m := &MatrixGenerator{
+			Generators: [2]ApplicationGenerator{
+				{GitDirectory: &GitDirectoryGenerator{}},
+				{List: &ListGenerator{Elements: []Params{{"tier": "web"}}}},
+			},
+		}
+		if _, err := m.Generate(); err == nil {
+			t.Fatal("Generate() expected the left generator's error to propagate")
+		}
+	})
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		name     string
+		appGens  []ApplicationGenerator
+		wantApps int
+		wantErrs int
+	}{
+		{
+			name: "single list generator expands to one application per element",
+			appGens: []ApplicationGenerator{
+				{
+					List:     &ListGenerator{Elements: []Params{{"name": "dev"}, {"name": "stage"}}},
+					Template: ApplicationTemplate{Name: "app-{{.name}}"},
+				},
+			},
+			wantApps: 2,
+		},
+		{
+			name: "no generator set on an ApplicationGenerator is an error",
+			appGens: []ApplicationGenerator{
+				{Template: ApplicationTemplate{Name: "app"}},
+			},
+			wantErrs: 1,
+		},
+		{
+			name: "more than one generator set on an ApplicationGenerator is an error",
+			appGens: []ApplicationGenerator{
+				{
+					List:         &ListGenerator{Elements: []Params{{"name": "dev"}}},
+					GitDirectory: &GitDirectoryGenerator{},
+					Template:     ApplicationTemplate{Name: "app"},
+				},
+			},
+			wantErrs: 1,
+		},
+		{
+			name: "invalid template is an error",
+			appGens: []ApplicationGenerator{
+				{
+					List:     &ListGenerator{Elements: []Params{{"name": "dev"}}},
+					Template: ApplicationTemplate{Name: "app-{{.name"},
+				},
+			},
+			wantErrs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apps, errs := Expand("environments[0]", tt.appGens)
+			if len(apps) != tt.wantApps {
+				t.Errorf("Expand() returned %d apps, want %d", len(apps), tt.wantApps)
+			}
+			if len(errs) != tt.wantErrs {
+				t.Errorf("Expand() returned %d errs, want %d: %v", len(errs), tt.wantErrs, errs)
+			}
+		})
+	}
+}
+
+func TestExpandCarriesGeneratorPathAndParams(t *testing.T) {
+	appGens := []ApplicationGenerator{
+		{
+			List:     &ListGenerator{Elements: []Params{{"name": "dev"}}},
+			Template: ApplicationTemplate{Name: "app-{{.name}}"},
+		},
+	}
+
+	apps, errs := Expand("environments[0]", appGens)
+	if len(errs) != 0 {
+		t.Fatalf("Expand() unexpected errors: %v", errs)
+	}
+	if len(apps) != 1 {
+		t.Fatalf("Expand() returned %d apps, want 1", len(apps))
+	}
+
+	got := apps[0]
+	wantPath := "environments[0].applicationGenerators[0].list"
+	if got.GeneratorPath != wantPath {
+		t.Errorf("GeneratorPath = %q, want %q", got.GeneratorPath, wantPath)
+	}
+	if got.Name != "app-dev" {
+		t.Errorf("Name = %q, want %q", got.Name, "app-dev")
+	}
+	if got.Params["name"] != "dev" {
+		t.Errorf("Params[name] = %q, want %q", got.Params["name"], "dev")
+	}
+}
@@ -0,0 +1,152 @@
+package generators
+
+import "fmt"
+
+// PullRequest is a single open, merged or closed pull/merge request as
+// reported by a PullRequestLister.
+type PullRequest struct {
+	Number  int
+	HeadSHA string
+	// State is one of "open", "merged" or "closed".
+	State string
+}
+
+// PullRequestLister queries a Git hosting provider (GitHub, GitLab,
+// Bitbucket, Gitea) for the pull requests on a service repository.
+type PullRequestLister interface {
+	ListPullRequests(provider, repo, labelFilter string) ([]PullRequest, error)
+}
+
+// EnvironmentTemplate is substituted with a PullRequestGenerator's Params
+// to produce a GeneratedEnvironment. Name and Namespace are rendered with
+// {{.prNumber}}/{{.headSHA}} available, mirroring ApplicationTemplate.
+type EnvironmentTemplate struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace"`
+	ImageTag  string `yaml:"imageTag"`
+}
+
+// PullRequestGenerator queries Repo on Provider for open pull requests
+// matching LabelFilter and generates one short-lived Environment per PR,
+// so that ephemeral preview environments can be materialized without a
+// hand-authored manifest entry per PR.
+type PullRequestGenerator struct {
+	Provider    string `yaml:"provider"`
+	Repo        string `yaml:"repo"`
+	LabelFilter string `yaml:"labelFilter,omitempty"`
+
+	Template EnvironmentTemplate `yaml:"template"`
+
+	Lister PullRequestLister `yaml:"-"`
+
+	path string
+}
+
+// GeneratedEnvironment is a concrete Environment produced by expanding a
+// PullRequestGenerator's PullRequest into its EnvironmentTemplate.
+type GeneratedEnvironment struct {
+	Name          string
+	Namespace     string
+	ImageTag      string
+	PRNumber      int
+	GeneratorPath string
+}
+
+// EnvironmentGenerator pairs a PullRequest generator with the Manifest it
+// belongs to. Additional environment-level generator kinds can be added
+// alongside PullRequest following the same pattern as ApplicationGenerator.
+type EnvironmentGenerator struct {
+	PullRequest *PullRequestGenerator `yaml:"pullRequest,omitempty"`
+}
+
+// ExpandEnvironments runs each of envGens' generators and substitutes the
+// resulting pull requests into its EnvironmentTemplate, returning one
+// GeneratedEnvironment per open pull request. manifestPath identifies the
+// owning manifest in error messages, e.g. "environmentGenerators".
+func ExpandEnvironments(manifestPath string, envGens []EnvironmentGenerator) ([]*GeneratedEnvironment, []error) {
+	envs := []*GeneratedEnvironment{}
+	errs := []error{}
+
+	for i := range envGens {
+		eg := &envGens[i]
+		path := fmt.Sprintf("%s[%d].pullRequest", manifestPath, i)
+		if eg.PullRequest == nil {
+			errs = append(errs, fmt.Errorf("%s: environmentGenerators currently only support pullRequest", path))
+			continue
+		}
+		eg.PullRequest.path = path
+
+		if eg.PullRequest.Lister == nil {
+			errs = append(errs, fmt.Errorf("%s: no pull request lister configured for %s", path, eg.PullRequest.Repo))
+			continue
+		}
+
+		prs, err := eg.PullRequest.Lister.ListPullRequests(eg.PullRequest.Provider, eg.PullRequest.Repo, eg.PullRequest.LabelFilter)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: failed to list pull requests for %s: %w", path, eg.PullRequest.Repo, err))
+			continue
+		}
+
+		for _, pr := range prs {
+			if pr.State != "open" {
+				continue
+			}
+			env, err := renderEnvironmentTemplate(path, eg.PullRequest.Template, pr)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			envs = append(envs, env)
+		}
+	}
+	return envs, errs
+}
+
+func renderEnvironmentTemplate(generatorPath string, tmpl EnvironmentTemplate, pr PullRequest) (*GeneratedEnvironment, error) {
+	params := Params{
+		"prNumber": fmt.Sprintf("%d", pr.Number),
+		"headSHA":  pr.HeadSHA,
+	}
+
+	name, err := execTemplate(generatorPath, "name", tmpl.Name, params)
+	if err != nil {
+		return nil, err
+	}
+	namespace, err := execTemplate(generatorPath, "namespace", tmpl.Namespace, params)
+	if err != nil {
+		return nil, err
+	}
+	imageTag, err := execTemplate(generatorPath, "imageTag", tmpl.ImageTag, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GeneratedEnvironment{
+		Name:          name,
+		Namespace:     namespace,
+		ImageTag:      imageTag,
+		PRNumber:      pr.Number,
+		GeneratorPath: generatorPath,
+	}, nil
+}
+
+// PruneClosedEnvironments returns the subset of existing whose pull
+// request is no longer open, according to prs. It is the reconcile hook
+// that `odo pipelines build` runs on every invocation so that merged or
+// closed PRs have their preview environment removed on the next build.
+func PruneClosedEnvironments(existing []*GeneratedEnvironment, prs []PullRequest) []*GeneratedEnvironment {
+	open := map[int]bool{}
+	for _, pr := range prs {
+		if pr.State == "open" {
+			open[pr.Number] = true
+		}
+	}
+
+	toPrune := []*GeneratedEnvironment{}
+	for _, env := range existing {
+		if !open[env.PRNumber] {
+			toPrune = append(toPrune, env)
+		}
+	}
+	return toPrune
+}
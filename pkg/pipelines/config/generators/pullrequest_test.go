@@ -0,0 +1,153 @@
+package generators
+
+import (
+	"fmt"
+	"testing"
+)
+
+type fakePullRequestLister struct {
+	prs []PullRequest
+	err error
+}
+
+func (f fakePullRequestLister) ListPullRequests(provider, repo, labelFilter string) ([]PullRequest, error) {
+	return f.prs, f.err
+}
+
+func TestExpandEnvironments(t *testing.T) {
+	t.Run("only open pull requests are expanded", func(t *testing.T) {
+		envGens := []EnvironmentGenerator{
+			{
+				PullRequest: &PullRequestGenerator{
+					Provider: "github",
+					Repo:     "org/repo",
+					Template: EnvironmentTemplate{
+						Name:      "pr-{{.prNumber}}",
+						Namespace: "preview-{{.prNumber}}",
+						ImageTag:  "{{.headSHA}}",
+					},
+					Lister: fakePullRequestLister{prs: []PullRequest{
+						{Number: 1, HeadSHA: "abc123", State: "open"},
+						{Number: 2, HeadSHA: "def456", State: "merged"},
+						{Number: 3, HeadSHA: "ghi789", State: "closed"},
+					}},
+				},
+			},
+		}
+
+		envs, errs := ExpandEnvironments("environmentGenerators", envGens)
+		if len(errs) != 0 {
+			t.Fatalf("ExpandEnvironments() unexpected errors: %v", errs)
+		}
+		if len(envs) != 1 {
+			t.Fatalf("ExpandEnvironments() returned %d envs, want 1", len(envs))
+		}
+		got := envs[0]
+		if got.Name != "pr-1" || got.Namespace != "preview-1" || got.ImageTag != "abc123" || got.PRNumber != 1 {
+			t.Errorf("ExpandEnvironments() = %+v, unexpected values", got)
+		}
+		wantPath := "environmentGenerators[0].pullRequest"
+		if got.GeneratorPath != wantPath {
+			t.Errorf("GeneratorPath = %q, want %q", got.GeneratorPath, wantPath)
+		}
+	})
+
+	t.Run("missing pullRequest generator is an error", func(t *testing.T) {
+		envGens := []EnvironmentGenerator{{}}
+		_, errs := ExpandEnvironments("environmentGenerators", envGens)
+		if len(errs) != 1 {
+			t.Fatalf("ExpandEnvironments() returned %d errs, want 1", len(errs))
+		}
+	})
+
+	t.Run("no lister configured is an error", func(t *testing.T) {
+		envGens := []EnvironmentGenerator{
+			{PullRequest: &PullRequestGenerator{Provider: "github", Repo: "org/repo"}},
+		}
+		_, errs := ExpandEnvironments("environmentGenerators", envGens)
+		if len(errs) != 1 {
+			t.Fatalf("ExpandEnvironments() returned %d errs, want 1", len(errs))
+		}
+	})
+
+	t.Run("lister error is wrapped", func(t *testing.T) {
+		envGens := []EnvironmentGenerator{
+			{
+				PullRequest: &PullRequestGenerator{
+					Provider: "github",
+					Repo:     "org/repo",
+					Lister:   fakePullRequestLister{err: fmt.Errorf("boom")},
+				},
+			},
+		}
+		_, errs := ExpandEnvironments("environmentGenerators", envGens)
+		if len(errs) != 1 {
+			t.Fatalf("ExpandEnvironments() returned %d errs, want 1", len(errs))
+		}
+	})
+
+	t.Run("invalid template is an error", func(t *testing.T) {
+		envGens := []EnvironmentGenerator{
+			{
+				PullRequest: &PullRequestGenerator{
+					Provider: "github",
+					Repo:     "org/repo",
+					Template: EnvironmentTemplate{Name: "pr-{{.prNumber"},
+					Lister:   fakePullRequestLister{prs: []PullRequest{{Number: 1, State: "open"}}},
+				},
+			},
+		}
+		_, errs := ExpandEnvironments("environmentGenerators", envGens)
+		if len(errs) != 1 {
+			t.Fatalf("ExpandEnvironments() returned %d errs, want 1", len(errs))
+		}
+	})
+}
+
+func TestPruneClosedEnvironments(t *testing.T) {
+	existing := []*GeneratedEnvironment{
+		{Name: "pr-1", PRNumber: 1},
+		{Name: "pr-2", PRNumber: 2},
+		{Name: "pr-3", PRNumber: 3},
+	}
+
+	tests := []struct {
+		name      string
+		prs       []PullRequest
+		wantPRNos []int
+	}{
+		{
+			name: "open PRs are kept, merged/closed are pruned",
+			prs: []PullRequest{
+				{Number: 1, State: "open"},
+				{Number: 2, State: "merged"},
+				{Number: 3, State: "closed"},
+			},
+			wantPRNos: []int{2, 3},
+		},
+		{
+			name:      "a PR missing from the list entirely is pruned",
+			prs:       []PullRequest{{Number: 1, State: "open"}},
+			wantPRNos: []int{2, 3},
+		},
+		{
+			name:      "all PRs open prunes nothing",
+			prs:       []PullRequest{{Number: 1, State: "open"}, {Number: 2, State: "open"}, {Number: 3, State: "open"}},
+			wantPRNos: []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pruned := PruneClosedEnvironments(existing, tt.prs)
+			if len(pruned) != len(tt.wantPRNos) {
+				t.Fatalf("PruneClosedEnvironments() returned %d envs, want %d", len(pruned), len(tt.wantPRNos))
+			}
+			for i, env := range pruned {
+				if env.PRNumber != tt.wantPRNos[i] {
+					t.Errorf("pruned[%d].PRNumber = %d, want %d", i, env.PRNumber, tt.wantPRNos[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/openshift/odo/pkg/pipelines/config/generators"
+	"sigs.k8s.io/yaml"
+)
+
+// Manifest is the root of a GitOps configuration: every Environment a
+// bootstrapped cluster manages, plus the shared Argo CD/pipeline defaults
+// in Config. It is the document `odo pipelines` commands read from and
+// write to as pipelines.yaml.
+type Manifest struct {
+	Environments          []*Environment                    `json:"environments,omitempty"`
+	EnvironmentGenerators []generators.EnvironmentGenerator `json:"environmentGenerators,omitempty"`
+	Config                *Config                           `json:"config,omitempty"`
+
+	// expanded guards expandGenerators/expandEnvironmentGenerators against
+	// running twice on the same Manifest (e.g. Validate called more than
+	// once), which would otherwise append the same generated
+	// Applications/Environments a second time.
+	expanded bool
+}
+
+// Environment is a single deploy target (e.g. "dev", "staging") a Manifest
+// manages: the Applications/Services it runs and, optionally, the
+// ApplicationGenerators used to expand additional Applications
+// dynamically.
+type Environment struct {
+	Name                  string                            `json:"name"`
+	Pipelines             *Pipelines                        `json:"pipelines,omitempty"`
+	ArgoCD                *ArgoCDOptions                    `json:"argoCD,omitempty"`
+	Applications          []*Application                    `json:"applications,omitempty"`
+	ApplicationGenerators []generators.ApplicationGenerator `json:"applicationGenerators,omitempty"`
+	Services              []*Service                        `json:"services,omitempty"`
+
+	// Namespace, ImageTag and PRNumber are set when this Environment was
+	// produced by an EnvironmentGenerator (e.g. PullRequest), carrying the
+	// values generators.GeneratedEnvironment resolved them to: the
+	// namespace its per-PR resources (CreateInternalRegistryResources)
+	// are provisioned into, the image tag its pipeline should
+	// build/deploy, and the pull request it tracks for
+	// PruneClosedEnvironments. All three are zero for a hand-authored
+	// Environment.
+	Namespace string `json:"-"`
+	ImageTag  string `json:"-"`
+	PRNumber  int    `json:"-"`
+}
+
+// Application is a single Argo CD Application within an Environment,
+// sourced either from an explicit list of Services or from a ConfigRepo
+// Argo CD renders directly.
+type Application struct {
+	Name        string         `json:"name"`
+	ServiceRefs []string       `json:"services,omitempty"`
+	ConfigRepo  *Repository    `json:"config_repo,omitempty"`
+	ArgoCD      *ArgoCDOptions `json:"argoCD,omitempty"`
+
+	// GeneratorPath is set when this Application was produced by an
+	// ApplicationGenerator, carrying generators.GeneratedApplication's
+	// GeneratorPath so a validation error on the resolved name/refs can be
+	// traced back to the generator that produced it. Empty for a
+	// hand-authored Application.
+	GeneratorPath string `json:"-"`
+}
+
+// Service is a single deployable component with its own source repository
+// and, optionally, its own CI pipeline and webhook.
+type Service struct {
+	Name      string         `json:"name"`
+	SourceURL string         `json:"source_url,omitempty"`
+	Webhook   *Webhook       `json:"webhook,omitempty"`
+	Pipelines *Pipelines     `json:"pipelines,omitempty"`
+	ArgoCD    *ArgoCDOptions `json:"argoCD,omitempty"`
+}
+
+// Repository points at a path within a Git repository, e.g. an
+// Application's ConfigRepo or a PullRequestGenerator's source.
+type Repository struct {
+	URL  string `json:"url"`
+	Path string `json:"path,omitempty"`
+}
+
+// SecretRef names a Kubernetes Secret a Webhook's secret value is read
+// from.
+type SecretRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// Webhook configures the secret used to authenticate push-event deliveries
+// for a Service.
+type Webhook struct {
+	Secret *SecretRef `json:"secret,omitempty"`
+}
+
+// Pipelines names the Tekton Integration a Service/Environment's CI runs
+// through.
+type Pipelines struct {
+	Integration *Integration `json:"integration,omitempty"`
+}
+
+// Integration names the pipeline bindings a Pipelines block runs with.
+type Integration struct {
+	Bindings []string `json:"bindings,omitempty"`
+}
+
+// Config carries the Manifest-wide Argo CD/pipeline defaults, distinct
+// from any one Environment/Application/Service.
+type Config struct {
+	ArgoCDConfig   *ArgoCDConfig   `json:"argoCD,omitempty"`
+	PipelineConfig *PipelineConfig `json:"pipelines,omitempty"`
+}
+
+// ArgoCDConfig names the namespace the Manifest's Argo CD instance runs
+// in.
+type ArgoCDConfig struct {
+	Namespace string `json:"namespace"`
+}
+
+// PipelineConfig names the default pipeline resources a Manifest's
+// Services run with.
+type PipelineConfig struct {
+	Name string `json:"name"`
+}
+
+// PipelinesConfig is the resolved, in-progress state threaded through
+// resource-generation functions (CreateInternalRegistryResources,
+// oidc.CreateResources, ...): which Manifest is being generated into, and
+// the on-disk path its GitOps repository lives at.
+type PipelinesConfig struct {
+	// OutputPath is the root of the GitOps configuration repository being
+	// generated, the same directory a Manifest is loaded from/saved to.
+	OutputPath string
+}
+
+// PathForPipelines returns the directory generated pipeline resources
+// (Tekton tasks/pipelines, Argo CD bootstrap resources) are written under.
+func PathForPipelines(cfg *PipelinesConfig) string {
+	if cfg == nil {
+		return "pipelines"
+	}
+	return cfg.OutputPath
+}
+
+// PathForEnvironment returns env's path within the GitOps configuration
+// repository, e.g. "environments/dev".
+func PathForEnvironment(env *Environment) string {
+	return fmt.Sprintf("environments/%s", env.Name)
+}
+
+// PathForApplication returns app's path within env, e.g.
+// "environments/dev/apps/my-app".
+func PathForApplication(env *Environment, app *Application) string {
+	return fmt.Sprintf("%s/apps/%s", PathForEnvironment(env), app.Name)
+}
+
+// PathForService returns svcName's path within env, e.g.
+// "environments/dev/services/my-service".
+func PathForService(env *Environment, svcName string) string {
+	return fmt.Sprintf("%s/services/%s", PathForEnvironment(env), svcName)
+}
+
+// Visitor is implemented by callers of Manifest.Walk that need to inspect
+// or validate every Environment/Application/Service in a Manifest.
+type Visitor interface {
+	Environment(env *Environment) error
+	Application(env *Environment, app *Application) error
+	Service(env *Environment, svc *Service) error
+}
+
+// Walk visits every Environment in m, then every Application and Service
+// within it, stopping at the first error any visitor method returns.
+func (m *Manifest) Walk(v Visitor) error {
+	for _, env := range m.Environments {
+		if err := v.Environment(env); err != nil {
+			return err
+		}
+		for _, app := range env.Applications {
+			if err := v.Application(env, app); err != nil {
+				return err
+			}
+		}
+		for _, svc := range env.Services {
+			if err := v.Service(env, svc); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ManifestListers bundles the generator Listers a loaded Manifest's
+// ApplicationGenerators/EnvironmentGenerators need to actually run, since
+// they're never marshaled as part of the manifest YAML itself
+// (yaml:"-").
+type ManifestListers struct {
+	GitDirectory generators.DirectoryLister
+	SCMProvider  generators.RepositoryLister
+	PullRequest  generators.PullRequestLister
+}
+
+// ParseManifest unmarshals a pipelines.yaml document and injects listers
+// into every GitDirectory/SCMProvider/PullRequest generator it declares,
+// so Validate's Matrix/GitDirectory/SCMProvider/PullRequest generators
+// have a working Lister instead of failing with "no ... configured" the
+// moment they're expanded.
+func ParseManifest(data []byte, listers ManifestListers) (*Manifest, error) {
+	m := &Manifest{}
+	if err := yaml.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("unable to parse manifest: %w", err)
+	}
+	m.injectListers(listers)
+	return m, nil
+}
+
+// injectListers walks every generator m declares and sets its Lister
+// field from listers, recursing into MatrixGenerator's children.
+func (m *Manifest) injectListers(listers ManifestListers) {
+	for _, env := range m.Environments {
+		for i := range env.ApplicationGenerators {
+			injectApplicationGeneratorListers(&env.ApplicationGenerators[i], listers)
+		}
+	}
+	for i := range m.EnvironmentGenerators {
+		eg := &m.EnvironmentGenerators[i]
+		if eg.PullRequest != nil {
+			eg.PullRequest.Lister = listers.PullRequest
+		}
+	}
+}
+
+func injectApplicationGeneratorListers(ag *generators.ApplicationGenerator, listers ManifestListers) {
+	if ag.GitDirectory != nil {
+		ag.GitDirectory.Lister = listers.GitDirectory
+	}
+	if ag.SCMProvider != nil {
+		ag.SCMProvider.Lister = listers.SCMProvider
+	}
+	if ag.Matrix != nil {
+		injectApplicationGeneratorListers(&ag.Matrix.Generators[0], listers)
+		injectApplicationGeneratorListers(&ag.Matrix.Generators[1], listers)
+	}
+}
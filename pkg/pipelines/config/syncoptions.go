@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ArgoCDOptions captures the gitops-engine sync-options/compare-options
+// annotations (argocd.argoproj.io/sync-options, .../compare-options) that
+// can be set on an Environment, Application or Service so individual
+// resources can opt out of pruning, force CreateNamespace/ServerSideApply,
+// or ignore extraneous live fields when Argo CD compares state.
+type ArgoCDOptions struct {
+	SyncOptions    []string `json:"syncOptions,omitempty"`
+	CompareOptions []string `json:"compareOptions,omitempty"`
+}
+
+// knownSyncOptionKeys are the sync-option keys gitops-engine recognizes;
+// each is expressed as "<Key>=true"/"<Key>=false" in the annotation value.
+var knownSyncOptionKeys = map[string]bool{
+	"Prune":           true,
+	"CreateNamespace": true,
+	"ServerSideApply": true,
+}
+
+// knownCompareOptions are the compare-option values gitops-engine
+// recognizes; these have no "=value" suffix.
+var knownCompareOptions = map[string]bool{
+	"IgnoreExtraneous": true,
+}
+
+// SyncOptionsAnnotation and CompareOptionsAnnotation are the annotation
+// keys the generated resources are stamped with when options are set.
+const (
+	SyncOptionsAnnotation    = "argocd.argoproj.io/sync-options"
+	CompareOptionsAnnotation = "argocd.argoproj.io/compare-options"
+)
+
+func validateSyncOptions(opts *ArgoCDOptions, path string) []error {
+	if opts == nil {
+		return nil
+	}
+	errs := []error{}
+	for _, opt := range opts.SyncOptions {
+		key, value, ok := splitOption(opt)
+		if !ok || !knownSyncOptionKeys[key] {
+			errs = append(errs, invalidSyncOptionError(opt, path))
+			continue
+		}
+		if value != "true" && value != "false" {
+			errs = append(errs, invalidSyncOptionError(opt, path))
+		}
+	}
+	for _, opt := range opts.CompareOptions {
+		if !knownCompareOptions[opt] {
+			errs = append(errs, invalidCompareOptionError(opt, path))
+		}
+	}
+	return errs
+}
+
+func splitOption(opt string) (key, value string, ok bool) {
+	parts := strings.SplitN(opt, "=", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// Annotations renders opts into the argocd.argoproj.io/sync-options and
+// .../compare-options annotation values expected on generated resources.
+func (opts *ArgoCDOptions) Annotations() map[string]string {
+	if opts == nil {
+		return nil
+	}
+	annotations := map[string]string{}
+	if len(opts.SyncOptions) > 0 {
+		annotations[SyncOptionsAnnotation] = strings.Join(opts.SyncOptions, ",")
+	}
+	if len(opts.CompareOptions) > 0 {
+		annotations[CompareOptionsAnnotation] = strings.Join(opts.CompareOptions, ",")
+	}
+	if len(annotations) == 0 {
+		return nil
+	}
+	return annotations
+}
+
+func invalidSyncOptionError(opt, path string) error {
+	return fmt.Errorf("invalid sync-option %q at %s: expected one of Prune/CreateNamespace/ServerSideApply=true|false", opt, path)
+}
+
+func invalidCompareOptionError(opt, path string) error {
+	return fmt.Errorf("invalid compare-option %q at %s: expected one of IgnoreExtraneous", opt, path)
+}
@@ -0,0 +1,76 @@
+package config
+
+import "testing"
+
+func TestValidateSyncOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    *ArgoCDOptions
+		wantErr int
+	}{
+		{"nil options", nil, 0},
+		{"valid sync options", &ArgoCDOptions{SyncOptions: []string{"Prune=true", "CreateNamespace=false"}}, 0},
+		{"unknown sync option key", &ArgoCDOptions{SyncOptions: []string{"Unknown=true"}}, 1},
+		{"sync option missing =value", &ArgoCDOptions{SyncOptions: []string{"Prune"}}, 1},
+		{"sync option with a non-bool value", &ArgoCDOptions{SyncOptions: []string{"Prune=maybe"}}, 1},
+		{"valid compare option", &ArgoCDOptions{CompareOptions: []string{"IgnoreExtraneous"}}, 0},
+		{"unknown compare option", &ArgoCDOptions{CompareOptions: []string{"Unknown"}}, 1},
+		{
+			"multiple errors accumulate",
+			&ArgoCDOptions{SyncOptions: []string{"Unknown=true"}, CompareOptions: []string{"Unknown"}},
+			2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateSyncOptions(tt.opts, "environments[0]")
+			if len(errs) != tt.wantErr {
+				t.Errorf("validateSyncOptions() returned %d errors, want %d: %v", len(errs), tt.wantErr, errs)
+			}
+		})
+	}
+}
+
+func TestArgoCDOptionsAnnotations(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *ArgoCDOptions
+		want map[string]string
+	}{
+		{"nil options", nil, nil},
+		{"no options set", &ArgoCDOptions{}, nil},
+		{
+			"sync options only",
+			&ArgoCDOptions{SyncOptions: []string{"Prune=true", "CreateNamespace=true"}},
+			map[string]string{SyncOptionsAnnotation: "Prune=true,CreateNamespace=true"},
+		},
+		{
+			"compare options only",
+			&ArgoCDOptions{CompareOptions: []string{"IgnoreExtraneous"}},
+			map[string]string{CompareOptionsAnnotation: "IgnoreExtraneous"},
+		},
+		{
+			"both set",
+			&ArgoCDOptions{SyncOptions: []string{"Prune=true"}, CompareOptions: []string{"IgnoreExtraneous"}},
+			map[string]string{
+				SyncOptionsAnnotation:    "Prune=true",
+				CompareOptionsAnnotation: "IgnoreExtraneous",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.opts.Annotations()
+			if len(got) != len(tt.want) {
+				t.Fatalf("Annotations() = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("Annotations()[%q] = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
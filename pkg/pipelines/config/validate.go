@@ -7,6 +7,8 @@ import (
 	"github.com/mkmik/multierror"
 	"k8s.io/apimachinery/pkg/api/validation"
 	"knative.dev/pkg/apis"
+
+	"github.com/openshift/odo/pkg/pipelines/config/generators"
 )
 
 type validateVisitor struct {
@@ -29,6 +31,11 @@ func (m *Manifest) Validate() error {
 	}
 
 	vv.errs = append(vv.errs, vv.validateConfig(m)...)
+	if !m.expanded {
+		vv.errs = append(vv.errs, m.expandEnvironmentGenerators()...)
+		vv.errs = append(vv.errs, m.expandGenerators()...)
+		m.expanded = true
+	}
 	err := m.Walk(vv)
 	if err != nil {
 		vv.errs = append(vv.errs, err)
@@ -66,20 +73,23 @@ func (vv *validateVisitor) Environment(env *Environment) error {
 	if err := validatePipelines(env.Pipelines, envPath); err != nil {
 		vv.errs = append(vv.errs, err...)
 	}
+	for _, err := range validateSyncOptions(env.ArgoCD, envPath) {
+		vv.errs = append(vv.errs, err)
+	}
 	return nil
 }
 
 func (vv *validateVisitor) Application(env *Environment, app *Application) error {
 	appPath := yamlPath(PathForApplication(env, app))
 	if err := checkDuplicate(app.Name, appPath, vv.appNames); err != nil {
-		vv.errs = append(vv.errs, err)
+		vv.errs = append(vv.errs, withGeneratorPath(err, app))
 	}
 	if err := validateName(app.Name, appPath); err != nil {
-		vv.errs = append(vv.errs, err)
+		vv.errs = append(vv.errs, withGeneratorPath(err, app))
 	}
 
 	if len(app.ServiceRefs) == 0 && app.ConfigRepo == nil {
-		vv.errs = append(vv.errs, missingFieldsError([]string{"services", "config_repo"}, []string{appPath}))
+		vv.errs = append(vv.errs, withGeneratorPath(missingFieldsError([]string{"services", "config_repo"}, []string{appPath}), app))
 	}
 	if len(app.ServiceRefs) > 0 && app.ConfigRepo != nil {
 		vv.errs = append(vv.errs, apis.ErrMultipleOneOf(yamlJoin(appPath, "services"), yamlJoin(appPath, "config_repo")))
@@ -92,13 +102,31 @@ func (vv *validateVisitor) Application(env *Environment, app *Application) error
 		for _, r := range app.ServiceRefs {
 			_, ok := vv.serviceNames[r]
 			if !ok {
-				vv.errs = append(vv.errs, missingServiceRefError(r, app.Name, []string{appPath}))
+				vv.errs = append(vv.errs, withGeneratorPath(missingServiceRefError(r, app.Name, []string{appPath}), app))
 			}
 		}
 	}
+	for _, err := range validateSyncOptions(app.ArgoCD, appPath) {
+		vv.errs = append(vv.errs, err)
+	}
 	return nil
 }
 
+// withGeneratorPath adds app.GeneratorPath to err's Paths when app was
+// produced by an ApplicationGenerator, so an error on its resolved name or
+// refs can be traced back to the generator/template that produced it.
+func withGeneratorPath(err error, app *Application) error {
+	if app.GeneratorPath == "" {
+		return err
+	}
+	fieldErr, ok := err.(*apis.FieldError)
+	if !ok {
+		return err
+	}
+	fieldErr.Paths = append(fieldErr.Paths, app.GeneratorPath)
+	return fieldErr
+}
+
 func (vv *validateVisitor) Service(env *Environment, svc *Service) error {
 	svcPath := yamlPath(PathForService(env, svc.Name))
 	if svc.SourceURL != "" {
@@ -121,6 +149,9 @@ func (vv *validateVisitor) Service(env *Environment, svc *Service) error {
 	if err := validatePipelines(svc.Pipelines, svcPath); err != nil {
 		vv.errs = append(vv.errs, err...)
 	}
+	for _, err := range validateSyncOptions(svc.ArgoCD, svcPath) {
+		vv.errs = append(vv.errs, err)
+	}
 	vv.serviceNames[svc.Name] = true
 	return nil
 }
@@ -172,6 +203,64 @@ func validatePipelines(pipelines *Pipelines, path string) []error {
 	}
 	return errs
 }
+
+// expandGenerators expands every Environment's ApplicationGenerators into
+// concrete Applications, appending them to that Environment before Walk
+// visits it. It must run before checkDuplicate/validateName so that
+// generated applications are validated exactly like hand-authored ones.
+func (m *Manifest) expandGenerators() []error {
+	errs := []error{}
+	for _, env := range m.Environments {
+		if len(env.ApplicationGenerators) == 0 {
+			continue
+		}
+		envPath := yamlPath(PathForEnvironment(env))
+		generated, genErrs := generators.Expand(envPath, env.ApplicationGenerators)
+		for _, err := range genErrs {
+			errs = append(errs, fmt.Errorf("failed to expand application generators for environment %q: %w", env.Name, err))
+		}
+		for _, g := range generated {
+			app := &Application{Name: g.Name, ServiceRefs: g.ServiceRefs, GeneratorPath: g.GeneratorPath}
+			if g.ConfigRepo != nil {
+				app.ConfigRepo = &Repository{URL: g.ConfigRepo.URL, Path: g.ConfigRepo.Path}
+			}
+			if g.ArgoCD != nil {
+				app.ArgoCD = &ArgoCDOptions{SyncOptions: g.ArgoCD.SyncOptions, CompareOptions: g.ArgoCD.CompareOptions}
+			}
+			env.Applications = append(env.Applications, app)
+		}
+	}
+	return errs
+}
+
+// expandEnvironmentGenerators expands the Manifest's EnvironmentGenerators
+// (e.g. PullRequest) into synthetic Environments, appending them to
+// m.Environments before Walk visits it. Callers that emit resources per
+// environment (CreateInternalRegistryResources, namespace/role-binding
+// writers) run over these synthetic environments exactly like hand-authored
+// ones, since by the time they run Validate has already merged them in.
+func (m *Manifest) expandEnvironmentGenerators() []error {
+	if len(m.EnvironmentGenerators) == 0 {
+		return nil
+	}
+
+	generated, genErrs := generators.ExpandEnvironments("environmentGenerators", m.EnvironmentGenerators)
+	errs := make([]error, 0, len(genErrs))
+	for _, err := range genErrs {
+		errs = append(errs, fmt.Errorf("failed to expand environment generators: %w", err))
+	}
+
+	for _, g := range generated {
+		m.Environments = append(m.Environments, &Environment{
+			Name:      g.Name,
+			Namespace: g.Namespace,
+			ImageTag:  g.ImageTag,
+			PRNumber:  g.PRNumber,
+		})
+	}
+	return errs
+}
+
 func (vv *validateVisitor) validateConfig(manifest *Manifest) []error {
 	errs := []error{}
 	if manifest.Config != nil {
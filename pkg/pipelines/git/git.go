@@ -0,0 +1,49 @@
+// Package git resolves Git hosting repositories across providers (GitHub,
+// GitLab, Gitea, Bitbucket Server/Stash) via go-scm, so pipelines commands
+// can validate access tokens and look up repository metadata without
+// depending on any one provider's SDK directly.
+package git
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/factory"
+)
+
+// Repository wraps a go-scm client scoped to a single driver/token, giving
+// callers a Client to make provider API calls with.
+type Repository struct {
+	Client *scm.Client
+}
+
+// NewRepositoryForDriver returns a Repository whose Client is configured
+// for driver (one of the names gitProviderKey maps UI selections to, e.g.
+// "github", "gitlab", "stash", "gitea") against baseURL, authenticated
+// with token.
+func NewRepositoryForDriver(driver, baseURL, repoName, token string) (*Repository, error) {
+	if repoName == "" {
+		return nil, fmt.Errorf("no repository given for driver %q", driver)
+	}
+	client, err := factory.NewClient(driver, baseURL, token)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create %s client for %s: %w", driver, baseURL, err)
+	}
+	return &Repository{Client: client}, nil
+}
+
+// GetRepoName extracts the "org/repo" path go-scm's Repositories.Find
+// expects from a repository URL, stripping any leading slash and trailing
+// ".git" suffix.
+func GetRepoName(u *url.URL) (string, error) {
+	if u == nil {
+		return "", fmt.Errorf("no repository URL given")
+	}
+	name := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if name == "" {
+		return "", fmt.Errorf("unable to parse repository name from %q", u.String())
+	}
+	return name, nil
+}
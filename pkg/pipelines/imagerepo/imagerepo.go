@@ -2,6 +2,7 @@ package imagerepo
 
 import (
 	"fmt"
+	"net"
 	"path/filepath"
 	"strings"
 
@@ -14,41 +15,217 @@ import (
 	res "github.com/openshift/odo/pkg/pipelines/resources"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// ValidateImageRepo validates the input image repo.  It determines if it is
-// for internal registry and prepend internal registry hostname if neccessary.
-func ValidateImageRepo(imageRepo, registryURL string) (bool, string, error) {
-	components := strings.Split(imageRepo, "/")
+// knownIndexes are the hostnames recognized as public registry indexes, so
+// that a two-component repo name (e.g. "library/busybox") isn't mistaken
+// for an internal <project>/<app> reference.
+var knownIndexes = map[string]bool{
+	"docker.io":           true,
+	"index.docker.io":     true,
+	"quay.io":             true,
+	"ghcr.io":             true,
+	"registry.gitlab.com": true,
+}
+
+// RegistryConfig describes the registries odo knows about when resolving a
+// user-supplied image repository: the internal registry hostname, any
+// mirrors configured for it, registries that should be treated as insecure
+// (CIDRs or hostnames), and extra hostnames recognized as public indexes.
+type RegistryConfig struct {
+	// InternalRegistryHostname is the hostname (and optional port) of the
+	// cluster's internal image registry, e.g.
+	// "image-registry.openshift-image-registry.svc:5000".
+	InternalRegistryHostname string
+	// Mirrors is an ordered list of hostnames tried in preference to
+	// InternalRegistryHostname when resolving internal repository
+	// references. The first configured mirror wins.
+	Mirrors []string
+	// InsecureRegistries is the set of hostnames and CIDRs to mark
+	// insecure, e.g. for self-signed or air-gapped registries.
+	InsecureRegistries []string
+	// KnownIndexes extends the built-in set of recognized public registry
+	// hostnames (docker.io, quay.io, ...).
+	KnownIndexes []string
+}
+
+// RepositoryInfo is the fully-resolved description of an image repository,
+// replacing the stringly-typed <bool, string> pair previously returned by
+// ValidateImageRepo. It is modeled on the Docker registry client's
+// RepositoryInfo.
+type RepositoryInfo struct {
+	// Index is the hostname (and optional port) of the registry that will
+	// ultimately be pushed to/pulled from, after mirror resolution.
+	Index string
+	// RemoteName is the repository path as understood by Index, e.g.
+	// "username/repository".
+	RemoteName string
+	// LocalName is the full reference odo should use locally, i.e.
+	// "<Index>/<RemoteName>".
+	LocalName string
+	// Official is true when the repository resolved to the configured
+	// internal registry hostname or one of its mirrors.
+	Official bool
+	// Insecure is true when Index matched an entry in
+	// RegistryConfig.InsecureRegistries.
+	Insecure bool
+}
+
+// TLSVerifyArg returns the "--tls-verify=false" flag BuildConfigs and
+// Tekton tasks that push to info.Index should be given when it's insecure,
+// or the empty string for a normally-verified registry.
+func (info *RepositoryInfo) TLSVerifyArg() string {
+	if info.Insecure {
+		return "--tls-verify=false"
+	}
+	return ""
+}
+
+// InsecureRegistryAnnotation marks a generated resource as pushing to a
+// registry that skipped TLS verification, mirroring how
+// config.SyncOptionsAnnotation/CompareOptionsAnnotation surface resolved
+// ArgoCDOptions as annotations on the resource itself.
+const InsecureRegistryAnnotation = "pipelines.odo.dev/insecure-registry"
+
+// Annotations returns the annotations a resource generated for info should
+// carry, so CreateInternalRegistryResources (and, once BuildConfig/Tekton
+// task generation exists, those generators) surface info.Insecure/
+// TLSVerifyArg without callers having to re-resolve it.
+func (info *RepositoryInfo) Annotations() map[string]string {
+	if !info.Insecure {
+		return nil
+	}
+	return map[string]string{InsecureRegistryAnnotation: "true"}
+}
 
-	// repo url has minimum of 2 components
-	if len(components) < 2 {
-		return false, "", imageRepoValidationErrors(imageRepo)
+// ParseRepositoryInfo validates name and resolves it into a RepositoryInfo
+// using cfg. It replaces ValidateImageRepo: name grammar is checked by
+// ValidateRepositoryName, and cfg (mirrors, insecure registries, known
+// indexes) is applied by ResolveRepositoryInfo.
+func ParseRepositoryInfo(name string, cfg *RegistryConfig) (*RepositoryInfo, error) {
+	components, err := ValidateRepositoryName(name)
+	if err != nil {
+		return nil, err
+	}
+	return ResolveRepositoryInfo(components, cfg)
+}
+
+// ValidateRepositoryName checks that name has the grammar
+// <registry>/<username>/<repository> or <project>/<app>, and returns its
+// "/"-separated components for ResolveRepositoryInfo to interpret.
+func ValidateRepositoryName(name string) ([]string, error) {
+	components := strings.Split(name, "/")
+
+	if len(components) < 2 || len(components) > 3 {
+		return nil, imageRepoValidationErrors(name)
 	}
 
 	for _, v := range components {
 		if isBlank(v) {
-			return false, "", imageRepoValidationErrors(imageRepo)
+			return nil, imageRepoValidationErrors(name)
 		}
 	}
 
+	return components, nil
+}
+
+// ResolveRepositoryInfo applies cfg to repository name components already
+// validated by ValidateRepositoryName, picking a mirror when the reference
+// is internal and marking the result insecure when it matches
+// cfg.InsecureRegistries.
+func ResolveRepositoryInfo(components []string, cfg *RegistryConfig) (*RepositoryInfo, error) {
+	name := strings.Join(components, "/")
+
 	if len(components) == 2 {
-		if components[0] == "docker.io" || components[0] == "quay.io" {
-			// we recognize docker.io and quay.io.  It is missing one component
-			return false, "", imageRepoValidationErrors(imageRepo)
+		if isKnownIndex(components[0], cfg) {
+			// <index>/<repository> is missing the username component.
+			return nil, imageRepoValidationErrors(name)
+		}
+		index := internalIndex(cfg)
+		if index == "" {
+			return nil, fmt.Errorf("%q looks like an internal <project>/<app> reference, but no internal registry hostname is configured", name)
+		}
+		return &RepositoryInfo{
+			Index:      index,
+			RemoteName: name,
+			LocalName:  index + "/" + name,
+			Official:   true,
+			Insecure:   isInsecure(index, cfg),
+		}, nil
+	}
+
+	// len(components) == 3: <registry>/<username>/<repository>
+	index := components[0]
+	remoteName := strings.Join(components[1:], "/")
+	return &RepositoryInfo{
+		Index:      index,
+		RemoteName: remoteName,
+		LocalName:  name,
+		Official:   index == cfg.internalHostname() || isMirror(index, cfg),
+		Insecure:   isInsecure(index, cfg),
+	}, nil
+}
+
+func isKnownIndex(host string, cfg *RegistryConfig) bool {
+	if knownIndexes[host] {
+		return true
+	}
+	if cfg == nil {
+		return false
+	}
+	for _, k := range cfg.KnownIndexes {
+		if k == host {
+			return true
+		}
+	}
+	return false
+}
+
+func isMirror(host string, cfg *RegistryConfig) bool {
+	if cfg == nil {
+		return false
+	}
+	for _, m := range cfg.Mirrors {
+		if m == host {
+			return true
+		}
+	}
+	return false
+}
+
+func isInsecure(host string, cfg *RegistryConfig) bool {
+	if cfg == nil {
+		return false
+	}
+	for _, r := range cfg.InsecureRegistries {
+		if r == host {
+			return true
+		}
+		if _, ipnet, err := net.ParseCIDR(r); err == nil {
+			if ip := net.ParseIP(host); ip != nil && ipnet.Contains(ip) {
+				return true
+			}
 		}
-		// We have format like <project>/<app> which is an internal registry.
-		// We prepend the internal registry hostname.
-		return true, registryURL + "/" + imageRepo, nil
 	}
+	return false
+}
 
-	// Check the first component to see if it is an internal registry
-	if len(components) == 3 {
-		return components[0] == registryURL, imageRepo, nil
+func (cfg *RegistryConfig) internalHostname() string {
+	if cfg == nil {
+		return ""
 	}
+	return cfg.InternalRegistryHostname
+}
 
-	// > 3 components.  invalid repo
-	return false, "", imageRepoValidationErrors(imageRepo)
+// internalIndex returns the hostname to use for an internal
+// <project>/<app> reference: the first configured mirror, falling back to
+// the internal registry hostname itself.
+func internalIndex(cfg *RegistryConfig) string {
+	if cfg != nil && len(cfg.Mirrors) > 0 {
+		return cfg.Mirrors[0]
+	}
+	return cfg.internalHostname()
 }
 
 func isBlank(s string) bool {
@@ -59,28 +236,46 @@ func imageRepoValidationErrors(imageRepo string) error {
 	return fmt.Errorf("failed to parse image repo:%s, expected image repository in the form <registry>/<username>/<repository> or <project>/<app> for internal registry", imageRepo)
 }
 
-<<<<<<< HEAD
-=======
 // CreateInternalRegistryResources creates the resources for accessing the
-// internal registry.
->>>>>>> 2de59d82a9c6d8dd41231e5bc4b88d18c9e7dd10
-func CreateInternalRegistryResources(cfg *config.PipelinesConfig, sa *corev1.ServiceAccount, imageRepo string) (res.Resources, error) {
+// internal registry. When annotations is non-empty (typically rendered
+// from an Environment/Application/Service's ArgoCDOptions) it is applied
+// to every generated resource so sync-options/compare-options annotations
+// reach the actual YAML.
+func CreateInternalRegistryResources(cfg *config.PipelinesConfig, sa *corev1.ServiceAccount, repoInfo *RepositoryInfo, annotations map[string]string) (res.Resources, error) {
 	// Provide access to service account for using internal registry
-	namespace := strings.Split(imageRepo, "/")[1]
+	namespace := strings.Split(repoInfo.RemoteName, "/")[0]
 
 	resources := res.Resources{}
 	filename := filepath.Join("01-namespaces", fmt.Sprintf("%s.yaml", namespace))
-<<<<<<< HEAD
 	namespacePath := filepath.Join(config.PathForPipelines(cfg), "base", "pipelines", filename)
 	resources[namespacePath] = namespaces.Create(namespace)
-	filenames = append(filenames, filename)
 
-	filename, roleBinding := createInternalRegistryRoleBinding(cfg, namespace, sa)
-=======
-	resources[filename] = namespaces.Create(namespace)
 	roleBinding := createInternalRegistryRoleBinding(cfg, namespace, sa)
->>>>>>> 2de59d82a9c6d8dd41231e5bc4b88d18c9e7dd10
-	return res.Merge(roleBinding, resources), nil
+	merged := res.Merge(roleBinding, resources)
+	applyAnnotations(merged, annotations)
+	return merged, nil
+}
+
+// applyAnnotations stamps annotations onto every resource that implements
+// metav1.Object, merging with any annotations the resource already carries.
+func applyAnnotations(resources res.Resources, annotations map[string]string) {
+	if len(annotations) == 0 {
+		return
+	}
+	for _, resource := range resources {
+		obj, ok := resource.(metav1.Object)
+		if !ok {
+			continue
+		}
+		existing := obj.GetAnnotations()
+		if existing == nil {
+			existing = map[string]string{}
+		}
+		for k, v := range annotations {
+			existing[k] = v
+		}
+		obj.SetAnnotations(existing)
+	}
 }
 
 func createInternalRegistryRoleBinding(cfg *config.PipelinesConfig, ns string, sa *corev1.ServiceAccount) res.Resources {
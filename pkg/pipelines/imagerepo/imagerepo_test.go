@@ -0,0 +1,171 @@
+package imagerepo
+
+import (
+	"testing"
+)
+
+func TestParseRepositoryInfo(t *testing.T) {
+	cfg := &RegistryConfig{
+		InternalRegistryHostname: "image-registry.openshift-image-registry.svc:5000",
+		Mirrors:                  []string{"mirror.example.com"},
+		InsecureRegistries:       []string{"insecure.example.com", "10.0.0.0/8"},
+		KnownIndexes:             []string{"registry.example.com"},
+	}
+
+	tests := []struct {
+		name      string
+		repo      string
+		cfg       *RegistryConfig
+		wantErr   bool
+		wantIndex string
+		wantLocal string
+		wantOffic bool
+		wantInsec bool
+	}{
+		{
+			name:      "internal project/app reference picks the first mirror",
+			repo:      "myproject/myapp",
+			cfg:       cfg,
+			wantIndex: "mirror.example.com",
+			wantLocal: "mirror.example.com/myproject/myapp",
+			wantOffic: true,
+		},
+		{
+			name:      "internal reference with no mirrors falls back to the internal hostname",
+			repo:      "myproject/myapp",
+			cfg:       &RegistryConfig{InternalRegistryHostname: "image-registry.openshift-image-registry.svc:5000"},
+			wantIndex: "image-registry.openshift-image-registry.svc:5000",
+			wantLocal: "image-registry.openshift-image-registry.svc:5000/myproject/myapp",
+			wantOffic: true,
+		},
+		{
+			name:    "internal reference with no internal registry configured is an error",
+			repo:    "myproject/myapp",
+			cfg:     &RegistryConfig{},
+			wantErr: true,
+		},
+		{
+			name:      "three component external reference",
+			repo:      "quay.io/username/repository",
+			cfg:       cfg,
+			wantIndex: "quay.io",
+			wantLocal: "quay.io/username/repository",
+			wantOffic: false,
+		},
+		{
+			name:      "three component reference against the internal hostname is official",
+			repo:      "image-registry.openshift-image-registry.svc:5000/myproject/myapp",
+			cfg:       cfg,
+			wantIndex: "image-registry.openshift-image-registry.svc:5000",
+			wantLocal: "image-registry.openshift-image-registry.svc:5000/myproject/myapp",
+			wantOffic: true,
+		},
+		{
+			name:      "three component reference against a configured mirror is official",
+			repo:      "mirror.example.com/myproject/myapp",
+			cfg:       cfg,
+			wantIndex: "mirror.example.com",
+			wantLocal: "mirror.example.com/myproject/myapp",
+			wantOffic: true,
+		},
+		{
+			name:      "hostname matching InsecureRegistries is insecure",
+			repo:      "insecure.example.com/username/repository",
+			cfg:       cfg,
+			wantIndex: "insecure.example.com",
+			wantLocal: "insecure.example.com/username/repository",
+			wantInsec: true,
+		},
+		{
+			name:      "hostname matching an insecure CIDR is insecure",
+			repo:      "10.1.2.3/username/repository",
+			cfg:       cfg,
+			wantIndex: "10.1.2.3",
+			wantLocal: "10.1.2.3/username/repository",
+			wantInsec: true,
+		},
+		{
+			name:    "a known index as a two component reference is missing its username",
+			repo:    "quay.io/repository",
+			cfg:     cfg,
+			wantErr: true,
+		},
+		{
+			name:    "a configured KnownIndexes hostname as a two component reference is missing its username",
+			repo:    "registry.example.com/repository",
+			cfg:     cfg,
+			wantErr: true,
+		},
+		{
+			name:    "too few components",
+			repo:    "myapp",
+			cfg:     cfg,
+			wantErr: true,
+		},
+		{
+			name:    "too many components",
+			repo:    "quay.io/org/username/repository",
+			cfg:     cfg,
+			wantErr: true,
+		},
+		{
+			name:    "blank component",
+			repo:    "quay.io//repository",
+			cfg:     cfg,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ParseRepositoryInfo(tt.repo, tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseRepositoryInfo(%q) expected an error, got none", tt.repo)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRepositoryInfo(%q) unexpected error: %s", tt.repo, err)
+			}
+			if info.Index != tt.wantIndex {
+				t.Errorf("Index: got %q, want %q", info.Index, tt.wantIndex)
+			}
+			if info.LocalName != tt.wantLocal {
+				t.Errorf("LocalName: got %q, want %q", info.LocalName, tt.wantLocal)
+			}
+			if info.Official != tt.wantOffic {
+				t.Errorf("Official: got %v, want %v", info.Official, tt.wantOffic)
+			}
+			if info.Insecure != tt.wantInsec {
+				t.Errorf("Insecure: got %v, want %v", info.Insecure, tt.wantInsec)
+			}
+		})
+	}
+}
+
+func TestRepositoryInfoTLSVerifyArg(t *testing.T) {
+	secure := &RepositoryInfo{Insecure: false}
+	if got := secure.TLSVerifyArg(); got != "" {
+		t.Errorf("TLSVerifyArg() for a secure registry = %q, want empty", got)
+	}
+
+	insecure := &RepositoryInfo{Insecure: true}
+	if got := insecure.TLSVerifyArg(); got != "--tls-verify=false" {
+		t.Errorf("TLSVerifyArg() for an insecure registry = %q, want --tls-verify=false", got)
+	}
+}
+
+func TestRepositoryInfoAnnotations(t *testing.T) {
+	secure := &RepositoryInfo{Insecure: false}
+	if got := secure.Annotations(); got != nil {
+		t.Errorf("Annotations() for a secure registry = %v, want nil", got)
+	}
+
+	insecure := &RepositoryInfo{Insecure: true}
+	want := map[string]string{InsecureRegistryAnnotation: "true"}
+	got := insecure.Annotations()
+	if len(got) != len(want) || got[InsecureRegistryAnnotation] != want[InsecureRegistryAnnotation] {
+		t.Errorf("Annotations() for an insecure registry = %v, want %v", got, want)
+	}
+}
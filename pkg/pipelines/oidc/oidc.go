@@ -0,0 +1,165 @@
+// Package oidc renders the Argo CD configuration and (optionally) the
+// oauth2-proxy deployment needed to wire a bootstrapped GitOps stack up to
+// an external OIDC/SSO identity provider, as gathered by the pipelines
+// wizard's OIDC prompts.
+package oidc
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/openshift/odo/pkg/pipelines/config"
+	"github.com/openshift/odo/pkg/pipelines/meta"
+	res "github.com/openshift/odo/pkg/pipelines/resources"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// argoCDNamespace is where Argo CD's argocd-cm/argocd-secret ConfigMap and
+// Secret are expected to live, matching the wizard's default ArgoCD
+// install namespace.
+const argoCDNamespace = "argocd"
+
+// oauth2ProxyImage pins the oauth2-proxy image generated Deployments use.
+const oauth2ProxyImage = "quay.io/oauth2-proxy/oauth2-proxy:v7.1.3"
+
+// Options describes an OIDC identity provider to wire Argo CD's SSO login
+// up to, and optionally an oauth2-proxy instance fronting the pipelines
+// dashboard, as gathered by SelectOptionOIDC/EnterOIDC*.
+type Options struct {
+	// IssuerURL is the OIDC provider's issuer, e.g.
+	// https://accounts.google.com. Its /.well-known/openid-configuration
+	// is expected to have already been validated by the caller.
+	IssuerURL string
+	// ClientID/ClientSecret are the OAuth2 client credentials registered
+	// with the provider for this cluster.
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback URL registered with the provider,
+	// typically the pipelines dashboard route plus /oauth2/callback.
+	RedirectURL string
+	// EnableOAuth2Proxy additionally fronts the pipelines dashboard with
+	// an oauth2-proxy Deployment, for clusters whose dashboard has no
+	// OIDC login flow of its own.
+	EnableOAuth2Proxy bool
+}
+
+// CreateResources patches argocd-cm and argocd-secret with opts' OIDC
+// configuration and, when opts.EnableOAuth2Proxy is set, adds an
+// oauth2-proxy Deployment/Service fronting the pipelines dashboard.
+func CreateResources(cfg *config.PipelinesConfig, opts Options) (res.Resources, error) {
+	base := filepath.Join(config.PathForPipelines(cfg), "base", "pipelines")
+	resources := res.Resources{
+		filepath.Join(base, "03-argocd", "argocd-cm.yaml"):     argoCDConfigMapPatch(opts),
+		filepath.Join(base, "03-argocd", "argocd-secret.yaml"): argoCDSecretPatch(opts),
+	}
+
+	if opts.EnableOAuth2Proxy {
+		resources[filepath.Join(base, "04-oauth2-proxy", "deployment.yaml")] = oauth2ProxyDeployment(opts)
+		resources[filepath.Join(base, "04-oauth2-proxy", "service.yaml")] = oauth2ProxyService()
+	}
+
+	return resources, nil
+}
+
+// argoCDConfigMapPatch renders the "oidc.config" key Argo CD's argocd-cm
+// reads its SSO configuration from.
+func argoCDConfigMapPatch(opts Options) *corev1.ConfigMap {
+	oidcConfig := fmt.Sprintf(`name: OIDC
+issuer: %s
+clientID: %s
+clientSecret: $oidc.clientSecret
+requestedScopes: ["openid", "profile", "email"]
+`, opts.IssuerURL, opts.ClientID)
+
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: meta.NamespacedName(argoCDNamespace, "argocd-cm"),
+		Data: map[string]string{
+			"oidc.config": oidcConfig,
+		},
+	}
+}
+
+// argoCDSecretPatch stashes the OIDC client secret under the key argocd-cm's
+// "$oidc.clientSecret" reference resolves to, so it never needs to be
+// written to argocd-cm itself in plaintext.
+func argoCDSecretPatch(opts Options) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: meta.NamespacedName(argoCDNamespace, "argocd-secret"),
+		StringData: map[string]string{
+			"oidc.clientSecret": opts.ClientSecret,
+		},
+	}
+}
+
+// oauth2ProxyDeployment renders an oauth2-proxy Deployment configured
+// against opts' identity provider, fronting the pipelines dashboard.
+func oauth2ProxyDeployment(opts Options) *appsv1.Deployment {
+	name := "oauth2-proxy"
+	replicas := int32(1)
+	labels := map[string]string{"app": name}
+
+	return &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: meta.NamespacedName(argoCDNamespace, name),
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  name,
+							Image: oauth2ProxyImage,
+							Args: []string{
+								"--provider=oidc",
+								"--oidc-issuer-url=" + opts.IssuerURL,
+								"--client-id=" + opts.ClientID,
+								"--client-secret=" + opts.ClientSecret,
+								"--redirect-url=" + opts.RedirectURL,
+								"--email-domain=*",
+								"--upstream=http://localhost:8080",
+								"--http-address=0.0.0.0:4180",
+							},
+							Ports: []corev1.ContainerPort{
+								{Name: "http", ContainerPort: 4180},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// oauth2ProxyService exposes the oauth2-proxy Deployment's HTTP port.
+func oauth2ProxyService() *corev1.Service {
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: meta.NamespacedName(argoCDNamespace, "oauth2-proxy"),
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": "oauth2-proxy"},
+			Ports: []corev1.ServicePort{
+				{Name: "http", Port: 80, TargetPort: intstr.FromString("http")},
+			},
+		},
+	}
+}
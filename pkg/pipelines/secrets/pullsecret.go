@@ -0,0 +1,81 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// dockerConfigJSON is the subset of a ~/.docker/config.json this package
+// needs to read: a map of registry hostname to its base64-encoded
+// "user:token" auth entry.
+type dockerConfigJSON struct {
+	Auths map[string]dockerConfigEntry `json:"auths"`
+}
+
+type dockerConfigEntry struct {
+	Auth string `json:"auth"`
+}
+
+// ValidatePullSecret checks that secret (a config.json/auth.json) carries a
+// well-formed auth entry for every registry in requiredRegistries, so a
+// pipeline never discovers a missing credential mid-run instead of at
+// bootstrap time. It returns a single error listing every registry that's
+// missing or malformed, or nil if requiredRegistries is fully covered.
+func ValidatePullSecret(secret []byte, requiredRegistries []string) error {
+	var cfg dockerConfigJSON
+	if err := json.Unmarshal(secret, &cfg); err != nil {
+		return fmt.Errorf("unable to parse pull secret: %w", err)
+	}
+
+	canonicalAuths := make(map[string]dockerConfigEntry, len(cfg.Auths))
+	for host, entry := range cfg.Auths {
+		canonicalAuths[canonicalizeRegistryHost(host)] = entry
+	}
+
+	var problems []string
+	for _, registry := range requiredRegistries {
+		host := canonicalizeRegistryHost(registry)
+		entry, ok := canonicalAuths[host]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: no auth entry found", registry))
+			continue
+		}
+		if err := validateAuthEntry(entry.Auth); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", registry, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("pull secret is missing required registries:\n%s", strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// canonicalizeRegistryHost treats docker.io and its historical v1 index
+// hostname as the same registry, leaving every other hostname untouched.
+func canonicalizeRegistryHost(host string) string {
+	switch host {
+	case "docker.io", "index.docker.io", "index.docker.io/v1/":
+		return "docker.io"
+	default:
+		return host
+	}
+}
+
+// validateAuthEntry base64-decodes auth and confirms it parses as
+// "user:token".
+func validateAuthEntry(auth string) error {
+	if auth == "" {
+		return fmt.Errorf("auth entry is empty")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(auth)
+	if err != nil {
+		return fmt.Errorf("auth entry is not valid base64: %w", err)
+	}
+	if !strings.Contains(string(decoded), ":") {
+		return fmt.Errorf("auth entry does not decode to user:token")
+	}
+	return nil
+}
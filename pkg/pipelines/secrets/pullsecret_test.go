@@ -0,0 +1,138 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+)
+
+func authFor(userPass string) string {
+	return base64.StdEncoding.EncodeToString([]byte(userPass))
+}
+
+func configJSON(auths map[string]string) []byte {
+	entries := make(map[string]dockerConfigEntry, len(auths))
+	for host, auth := range auths {
+		entries[host] = dockerConfigEntry{Auth: auth}
+	}
+	data, err := json.Marshal(dockerConfigJSON{Auths: entries})
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func TestValidatePullSecret(t *testing.T) {
+	tests := []struct {
+		name               string
+		secret             []byte
+		requiredRegistries []string
+		wantErr            bool
+	}{
+		{
+			name:               "every required registry present and well-formed",
+			secret:             configJSON(map[string]string{"quay.io": authFor("user:token")}),
+			requiredRegistries: []string{"quay.io"},
+			wantErr:            false,
+		},
+		{
+			name:               "missing registry",
+			secret:             configJSON(map[string]string{"quay.io": authFor("user:token")}),
+			requiredRegistries: []string{"ghcr.io"},
+			wantErr:            true,
+		},
+		{
+			name:               "auth entry is not valid base64",
+			secret:             configJSON(map[string]string{"quay.io": "not-base64!!"}),
+			requiredRegistries: []string{"quay.io"},
+			wantErr:            true,
+		},
+		{
+			name:               "auth entry does not decode to user:token",
+			secret:             configJSON(map[string]string{"quay.io": authFor("notauserpass")}),
+			requiredRegistries: []string{"quay.io"},
+			wantErr:            true,
+		},
+		{
+			name:               "empty auth entry",
+			secret:             configJSON(map[string]string{"quay.io": ""}),
+			requiredRegistries: []string{"quay.io"},
+			wantErr:            true,
+		},
+		{
+			name:               "docker.io canonicalizes with index.docker.io",
+			secret:             configJSON(map[string]string{"index.docker.io": authFor("user:token")}),
+			requiredRegistries: []string{"docker.io"},
+			wantErr:            false,
+		},
+		{
+			name:               "docker.io canonicalizes with the historical v1 index hostname",
+			secret:             configJSON(map[string]string{"index.docker.io/v1/": authFor("user:token")}),
+			requiredRegistries: []string{"docker.io"},
+			wantErr:            false,
+		},
+		{
+			name:               "invalid JSON",
+			secret:             []byte("not json"),
+			requiredRegistries: []string{"quay.io"},
+			wantErr:            true,
+		},
+		{
+			name:               "no required registries is always satisfied",
+			secret:             configJSON(map[string]string{}),
+			requiredRegistries: nil,
+			wantErr:            false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePullSecret(tt.secret, tt.requiredRegistries)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePullSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCanonicalizeRegistryHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"docker.io", "docker.io"},
+		{"index.docker.io", "docker.io"},
+		{"index.docker.io/v1/", "docker.io"},
+		{"quay.io", "quay.io"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := canonicalizeRegistryHost(tt.host); got != tt.want {
+				t.Errorf("canonicalizeRegistryHost(%q) = %q, want %q", tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAuthEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    string
+		wantErr bool
+	}{
+		{"empty", "", true},
+		{"not base64", "not-base64!!", true},
+		{"valid user:token", authFor("user:token"), false},
+		{"decodes but missing colon", authFor("usertoken"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAuthEntry(tt.auth)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateAuthEntry(%q) error = %v, wantErr %v", tt.auth, err, tt.wantErr)
+			}
+		})
+	}
+}